@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/himattm/prism/internal/bench"
+)
+
+const benchUsage = `Usage: prism bench [-n N] [--cold] [--input FILE] [--budget MS]
+
+Render a recorded status line N times (default 100) against a fixture git
+repo and a fake adb, and report p50/p95 latency per section and in total.
+
+Options:
+  -n N          Number of renders
+  --cold        Fresh plugin caches for every render (like a one-shot process);
+                by default caches stay warm (like the daemon)
+  --input FILE  Replay this Claude Code status line JSON instead of the built-in recording
+  --budget MS   Render budget in milliseconds (default: renderBudget, else 600)`
+
+func handleBench(args []string) {
+	cfg := bench.Config()
+	opts := bench.Options{Iterations: 100}
+	inputFile := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		next := func() string {
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s needs a value\n\n%s\n", arg, benchUsage)
+				os.Exit(1)
+			}
+			i++
+			return args[i]
+		}
+
+		switch arg {
+		case "-n":
+			n, err := strconv.Atoi(next())
+			if err != nil || n <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: -n needs a positive number")
+				os.Exit(1)
+			}
+			opts.Iterations = n
+		case "--cold":
+			opts.Cold = true
+		case "--input":
+			inputFile = next()
+		case "--budget":
+			ms, err := strconv.Atoi(next())
+			if err != nil || ms <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: --budget needs a positive number of milliseconds")
+				os.Exit(1)
+			}
+			cfg.RenderBudget = ms
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown bench option: %s\n\n%s\n", arg, benchUsage)
+			os.Exit(1)
+		}
+	}
+
+	fixture, err := bench.NewFixture()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating the fixture: %v\n", err)
+		os.Exit(1)
+	}
+	defer fixture.Close()
+
+	opts.Input = fixture.Input()
+	if inputFile != "" {
+		input, err := readPreviewInput(inputFile)
+		if err != nil {
+			fixture.Close()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		input.Workspace = opts.Input.Workspace
+		opts.Input = input
+	}
+	opts.Config = cfg
+
+	bench.Run(opts).Write(os.Stdout)
+}
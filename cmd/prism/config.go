@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/himattm/prism/internal/config"
+)
+
+func handleConfigCommand(args []string) {
+	if len(args) == 0 {
+		args = []string{"show"}
+	}
+
+	projectDir, _ := os.Getwd()
+
+	switch args[0] {
+	case "validate", "check":
+		handleConfigValidate(projectDir)
+
+	case "show":
+		jsonOutput := len(args) > 1 && args[1] == "--json"
+		handleConfigShow(projectDir, jsonOutput)
+
+	case "schema":
+		os.Stdout.Write(config.Schema())
+
+	case "migrate":
+		dryRun := len(args) > 1 && args[1] == "--dry-run"
+		handleConfigMigrate(projectDir, dryRun)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: prism config [validate|show [--json]|schema|migrate [--dry-run]]")
+		os.Exit(1)
+	}
+}
+
+func handleConfigValidate(projectDir string) {
+	checked := 0
+	for _, tier := range config.Tiers(projectDir) {
+		if _, err := os.Stat(tier.Path); err == nil {
+			checked++
+		}
+	}
+
+	for _, tier := range config.Tiers(projectDir) {
+		data, err := os.ReadFile(tier.Path)
+		if err != nil {
+			continue
+		}
+		for _, change := range config.Deprecations(data) {
+			fmt.Printf("⚠ %s: %s\n", tier.Path, change)
+		}
+	}
+
+	problems := append(config.ValidateFiles(projectDir), config.ValidateOverrides()...)
+	if len(problems) == 0 {
+		fmt.Printf("✓ %d config file(s) valid\n", checked)
+		return
+	}
+
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "✗ %s\n", p)
+	}
+	fmt.Fprintf(os.Stderr, "\n%d problem(s) found\n", len(problems))
+	os.Exit(1)
+}
+
+func handleConfigShow(projectDir string, jsonOutput bool) {
+	cfg, sources := config.Explain(projectDir, projectDir)
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		fmt.Println(string(data))
+		return
+	}
+
+	var merged map[string]any
+	json.Unmarshal(data, &merged)
+
+	fmt.Println("Config files (lowest to highest precedence):")
+	for _, tier := range config.Tiers(projectDir) {
+		status := "not found"
+		if _, err := os.Stat(tier.Path); err == nil {
+			status = "loaded"
+		}
+		fmt.Printf("  %-8s %s (%s)\n", tier.Name, tier.Path, status)
+	}
+	if name, reason := config.ActiveProfile(projectDir, projectDir); name != "" {
+		fmt.Printf("  %-8s %s (%s)\n", "profile", name, reason)
+	}
+	for _, o := range config.Overrides() {
+		if o.Key != "" {
+			fmt.Printf("  %-8s %s\n", "override", o.Source)
+		}
+	}
+
+	if len(sources) == 0 {
+		fmt.Println("\nNo settings found; using defaults.")
+		return
+	}
+
+	keys := make([]string, 0, len(sources))
+	width := 0
+	for key := range sources {
+		keys = append(keys, key)
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	fmt.Println("\nEffective settings:")
+	for _, key := range keys {
+		value, ok := lookupPath(merged, key)
+		if !ok {
+			continue // Not a config key (e.g. "$schema") or an empty value
+		}
+		encoded, _ := json.Marshal(value)
+		fmt.Printf("  %-*s  %-8s %s\n", width, key, sources[key].Name, encoded)
+	}
+}
+
+func handleConfigMigrate(projectDir string, dryRun bool) {
+	migrated := 0
+	failed := false
+	for _, tier := range config.Tiers(projectDir) {
+		data, err := os.ReadFile(tier.Path)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(tier.Path)
+		if err != nil {
+			continue
+		}
+
+		updated, changes, err := config.Migrate(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", tier.Path, err)
+			failed = true
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s (%s)\n", tier.Path, tier.Name)
+		for _, change := range changes {
+			fmt.Printf("  • %s\n", change)
+		}
+		fmt.Println()
+		fmt.Print(config.Diff(data, updated))
+		fmt.Println()
+
+		if dryRun {
+			migrated++
+			continue
+		}
+
+		// Keep the file's permissions, e.g. a local file only you may read
+		perm := info.Mode().Perm()
+		backup := tier.Path + ".bak"
+		if err := os.WriteFile(backup, data, perm); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: cannot write backup: %v\n", tier.Path, err)
+			failed = true
+			continue
+		}
+		if err := os.WriteFile(tier.Path, updated, perm); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", tier.Path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("✓ Migrated; backup saved to %s\n\n", backup)
+		migrated++
+	}
+
+	switch {
+	case failed:
+		os.Exit(1)
+	case migrated == 0:
+		fmt.Println("✓ Config files already use the current schema")
+	case dryRun:
+		fmt.Printf("%d file(s) would be migrated; run without --dry-run to apply\n", migrated)
+	}
+}
+
+// lookupPath finds "key" or "key.name" in the marshaled config
+func lookupPath(merged map[string]any, path string) (any, bool) {
+	key, name, nested := strings.Cut(path, ".")
+	value, ok := merged[key]
+	if !ok || !nested {
+		return value, ok
+	}
+	entries, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	value, ok = entries[name]
+	return value, ok
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/himattm/prism/internal/daemon"
+)
+
+func handleDaemonCommand(args []string) {
+	if len(args) == 0 {
+		args = []string{"start"}
+	}
+
+	switch args[0] {
+	case "start":
+		startDaemon()
+
+	case "run":
+		// Foreground mode (used by "start", handy for debugging)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := daemon.NewServer().Serve(ctx, daemon.SocketPath()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "stop":
+		if err := daemon.Stop(); err != nil {
+			fmt.Println("Daemon is not running")
+			return
+		}
+		fmt.Println("Daemon stopped")
+
+	case "status":
+		ver, err := daemon.Ping()
+		if err != nil {
+			fmt.Println("Daemon is not running")
+			os.Exit(1)
+		}
+		fmt.Printf("Daemon running (version %s) on %s\n", ver, daemon.SocketPath())
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown daemon command: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: prism daemon [start|stop|status|run]")
+		os.Exit(1)
+	}
+}
+
+// startDaemon spawns a detached "prism daemon run" and waits for it to answer
+func startDaemon() {
+	if ver, err := daemon.Ping(); err == nil {
+		fmt.Printf("Daemon already running (version %s)\n", ver)
+		return
+	}
+
+	if err := spawnDaemon(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting daemon: %v\n", err)
+		os.Exit(1)
+	}
+
+	for i := 0; i < 20; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := daemon.Ping(); err == nil {
+			fmt.Printf("Daemon started on %s\n", daemon.SocketPath())
+			return
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Daemon did not start in time")
+	os.Exit(1)
+}
+
+// spawnDaemon starts a detached "prism daemon run" without waiting for it
+func spawnDaemon() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, "daemon", "run")
+	cmd.Stdin = nil
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	// Detach from parent process group so the daemon outlives this shell
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	return cmd.Start()
+}
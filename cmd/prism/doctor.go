@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/himattm/prism/internal/doctor"
+)
+
+func handleDoctor() {
+	projectDir, _ := os.Getwd()
+
+	failed := 0
+	for _, r := range doctor.Run(projectDir) {
+		mark := "✓"
+		switch r.Status {
+		case doctor.Warn:
+			mark = "⚠"
+		case doctor.Fail:
+			mark = "✗"
+			failed++
+		}
+		fmt.Printf("%s %-16s %s\n", mark, r.Name, r.Detail)
+		if r.Hint != "" && r.Status != doctor.OK {
+			fmt.Printf("  %-16s → %s\n", "", r.Hint)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d problem(s) found\n", failed)
+		os.Exit(1)
+	}
+}
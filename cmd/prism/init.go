@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/project"
+	"github.com/himattm/prism/internal/statusline"
+)
+
+func handleInit(args []string) {
+	yes := false
+	for _, arg := range args {
+		switch arg {
+		case "--yes", "-y":
+			yes = true
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown init option: %s\n", arg)
+			fmt.Fprintln(os.Stderr, "Usage: prism init [--yes]")
+			os.Exit(1)
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "prism.json")); err == nil {
+		fmt.Fprintln(os.Stderr, "Error: .claude/prism.json already exists")
+		os.Exit(1)
+	}
+
+	info := project.Detect(dir)
+	if len(info.Kinds) > 0 {
+		fmt.Printf("Detected: %s\n", strings.Join(info.Names(), ", "))
+	} else {
+		fmt.Println("No known project type detected; using the default sections.")
+	}
+
+	cfg := project.Suggest(info)
+
+	in := bufio.NewReader(os.Stdin)
+	if !yes {
+		var proposed []string
+		for _, section := range cfg.GetSections() {
+			proposed = append(proposed, section.Name)
+		}
+		sections := askList(in, "Sections", proposed)
+		if len(sections) == 0 {
+			sections = proposed
+		}
+		project.SetSections(&cfg, sections)
+
+		if info.Has(project.Android) {
+			options, _ := cfg.Plugins["android_devices"].(map[string]any)
+			packages := askList(in, "Android packages to show app versions for", info.ApplicationIDs)
+			if len(packages) > 0 {
+				options["packages"] = toAny(packages)
+			} else {
+				delete(options, "packages")
+			}
+		}
+	}
+
+	fmt.Println("\nPreview:")
+	sample, _ := statusline.FindScenario(dir, "default")
+	engine := statusline.NewPreviewEngine(sample.Usage)
+	fmt.Printf("  %s\n\n", engine.New(sample.Input, cfg).Render())
+	engine.Close()
+
+	if !yes && !askYes(in, "Write .claude/prism.json?") {
+		fmt.Println("Nothing written.")
+		return
+	}
+
+	if err := config.Init(dir, cfg); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			err = fmt.Errorf(".claude/prism.json already exists")
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Created .claude/prism.json")
+}
+
+// askList shows the proposed values and returns them, or the comma- or
+// space-separated values the user types instead ("-" for none)
+func askList(in *bufio.Reader, prompt string, proposed []string) []string {
+	fmt.Printf("%s [%s]: ", prompt, strings.Join(proposed, ", "))
+	answer, err := readAnswer(in)
+	if err != nil || answer == "" {
+		return proposed
+	}
+	if answer == "-" {
+		return nil
+	}
+	return strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// askYes asks a yes/no question that defaults to yes
+func askYes(in *bufio.Reader, prompt string) bool {
+	fmt.Printf("%s [Y/n]: ", prompt)
+	answer, err := readAnswer(in)
+	if err != nil {
+		return true
+	}
+	answer = strings.ToLower(answer)
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// readAnswer reads one line; at end of input the defaults apply
+func readAnswer(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		fmt.Println()
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// toAny converts a list to the []any shape config values have after JSON decoding
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/himattm/prism/internal/logging"
+)
+
+const logsUsage = `Usage: prism logs [options]
+
+Show the debug log (enable it with "log_level" in the config).
+
+Options:
+  -n N             Show the last N entries (default 50, 0 for all)
+  -f, --follow     Keep printing new entries until Ctrl-C
+  --level LEVEL    Only entries at LEVEL or above (debug, info, warn, error)
+  --event NAME     Only events starting with NAME, e.g. hook, plugin, update
+  --grep TEXT      Only entries containing TEXT
+  --json           Print the raw JSON lines
+  --path           Print the log file path`
+
+func handleLogs(args []string) {
+	filter := logging.Filter{Level: logging.LevelDebug}
+	limit := 50
+	follow, raw := false, false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		next := func() string {
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s needs a value\n\n%s\n", arg, logsUsage)
+				os.Exit(1)
+			}
+			i++
+			return args[i]
+		}
+
+		switch arg {
+		case "-f", "--follow":
+			follow = true
+		case "--json":
+			raw = true
+		case "--path":
+			fmt.Println(logging.Path())
+			return
+		case "-n":
+			n, err := strconv.Atoi(next())
+			if err != nil || n < 0 {
+				fmt.Fprintf(os.Stderr, "Error: -n needs a number\n")
+				os.Exit(1)
+			}
+			limit = n
+		case "--level":
+			level, err := logging.ParseLevel(next())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			filter.Level = level
+		case "--event":
+			filter.Event = next()
+		case "--grep":
+			filter.Text = next()
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown logs option: %s\n\n%s\n", arg, logsUsage)
+			os.Exit(1)
+		}
+	}
+
+	entries := logging.Read(filter)
+	if len(entries) == 0 && !follow {
+		if _, err := os.Stat(logging.Path()); err != nil {
+			fmt.Printf("No log at %s\n", logging.Path())
+			fmt.Println(`Set "log_level": "info" (or "debug") in ~/.claude/prism-config.json to enable it.`)
+		}
+		return
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	for _, e := range entries {
+		printLogEntry(e, raw)
+	}
+
+	if follow {
+		followLog(filter, raw)
+	}
+}
+
+func printLogEntry(e logging.Entry, raw bool) {
+	if raw {
+		fmt.Println(e.Raw)
+	} else {
+		fmt.Println(e)
+	}
+}
+
+// followLog prints entries appended to the log until interrupted, reopening
+// the file when it is rotated
+func followLog(filter logging.Filter, raw bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var file *os.File
+	var reader *bufio.Reader
+	var offset int64
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	// Start at the current end; everything before was printed already
+	if info, err := os.Stat(logging.Path()); err == nil {
+		offset = info.Size()
+	}
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		info, err := os.Stat(logging.Path())
+		if err == nil && (file == nil || info.Size() < offset) {
+			// First open, or the log was rotated and started over
+			if file != nil {
+				file.Close()
+				offset = 0
+			}
+			if file, err = os.Open(logging.Path()); err == nil {
+				file.Seek(offset, io.SeekStart)
+				reader = bufio.NewReader(file)
+			} else {
+				file = nil
+			}
+		}
+
+		for file != nil {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				// Partial line: read it again once it is complete
+				file.Seek(offset, io.SeekStart)
+				reader.Reset(file)
+				break
+			}
+			offset += int64(len(line))
+			if e, err := logging.ParseEntry(strings.TrimSuffix(line, "\n")); err == nil && filter.Match(e) {
+				printLogEntry(e, raw)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,14 +3,19 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/himattm/prism/internal/colors"
 	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/daemon"
 	"github.com/himattm/prism/internal/hooks"
+	"github.com/himattm/prism/internal/logging"
 	"github.com/himattm/prism/internal/plugin"
 	"github.com/himattm/prism/internal/plugins"
 	"github.com/himattm/prism/internal/statusline"
@@ -19,14 +24,20 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
 		// No args = status line mode (read JSON from stdin)
 		runStatusLine()
 		return
 	}
 
 	// CLI mode
-	switch os.Args[1] {
+	switch args[0] {
 	case "version", "--version", "-v":
 		fmt.Printf("Prism %s (Go)\n", version.Version)
 
@@ -34,80 +45,198 @@ func main() {
 		printHelp()
 
 	case "plugin", "plugins":
-		handlePluginCommand(os.Args[2:])
+		handlePluginCommand(args[1:])
 
 	case "update":
-		autoMode := len(os.Args) > 2 && os.Args[2] == "--auto"
+		autoMode := len(args) > 1 && args[1] == "--auto"
 		handleUpdate(autoMode)
 
 	case "check-update":
 		handleCheckUpdate()
 
 	case "init":
-		handleInit()
+		handleInit(args[1:])
 
 	case "init-global":
 		handleInitGlobal()
 
+	case "preview":
+		handlePreview(args[1:])
+
+	case "render":
+		handleRender(args[1:])
+
+	case "doctor":
+		handleDoctor()
+
+	case "logs", "log":
+		handleLogs(args[1:])
+
+	case "bench":
+		handleBench(args[1:])
+
 	case "hook":
-		if len(os.Args) < 3 {
+		if len(args) < 2 {
 			fmt.Fprintln(os.Stderr, "Usage: prism hook <idle|busy>")
 			os.Exit(1)
 		}
-		handleHook(os.Args[2])
+		handleHook(args[1])
 
 	case "refract":
 		handleRefract()
 
+	case "daemon":
+		handleDaemonCommand(args[1:])
+
+	case "config":
+		handleConfigCommand(args[1:])
+
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
 		fmt.Fprintln(os.Stderr, "Run 'prism help' for usage")
 		os.Exit(1)
 	}
 }
 
+// parseGlobalFlags removes the flags that work with every command from args:
+// "--set key=value" (or "--set=key=value") config overrides, and --verbose,
+// which reports deprecated config keys on stderr
+func parseGlobalFlags(args []string) ([]string, error) {
+	var rest []string
+	var overrides []config.Override
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--verbose" {
+			config.SetWarningOutput(os.Stderr)
+			continue
+		}
+		value, isSet := strings.CutPrefix(arg, "--set=")
+		if arg == "--set" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--set needs a key=value argument")
+			}
+			i++
+			value, isSet = args[i], true
+		}
+		if !isSet {
+			rest = append(rest, arg)
+			continue
+		}
+		o, err := config.ParseSetFlag(value)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	config.SetFlagOverrides(overrides)
+	return rest, nil
+}
+
+// oneShotRefreshWait bounds how long a status line process stays around
+// for background refreshes after writing its output
+const oneShotRefreshWait = 1 * time.Second
+
 func runStatusLine() {
 	// Read JSON input from stdin
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Let a running daemon answer from its warm caches. It renders for this
+	// terminal (COLUMNS, NO_COLOR, TERM, ... are forwarded) with this
+	// process's PRISM_* variables and --set flags.
+	if output, err := daemon.Render(data); err == nil {
+		fmt.Print(output)
+		return
+	}
+
 	var input statusline.Input
-	decoder := json.NewDecoder(os.Stdin)
-	if err := decoder.Decode(&input); err != nil {
+	if err := json.Unmarshal(data, &input); err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Load config
-	cfg := config.Load(input.Workspace.ProjectDir)
+	cfg := config.LoadWorkspace(input.Workspace.ProjectDir, input.Workspace.CurrentDir)
+	logging.Configure(cfg.LogLevel)
 
 	// Build and render status line
-	sl := statusline.New(input, cfg)
+	engine := statusline.NewEngine()
+	defer engine.Close()
+	sl := engine.New(input, cfg)
 	output := sl.Render()
 
 	fmt.Print(output)
+
+	// Hand the output to Claude Code right away, then give slow sections a
+	// moment to finish so their fresh values are ready for the next refresh.
+	// Only briefly: Claude Code starts a new process for every refresh.
+	os.Stdout.Close()
+	sl.WaitTimeout(oneShotRefreshWait)
+
+	// A persistent plugin only stays running in a daemon; without one it was
+	// started for this render and is stopped again on return
+	if engine.StartedPersistent() {
+		if err := spawnDaemon(); err != nil {
+			logging.Warn("daemon.autostart", "reason", "persistent plugin", "error", err)
+		} else {
+			logging.Info("daemon.autostart", "reason", "persistent plugin")
+		}
+	}
 }
 
 func printHelp() {
 	fmt.Printf(`Prism %s - A fast, customizable status line for Claude Code
 
 Usage:
-  prism init                  Create .claude/prism.json in current directory
+  prism init [--yes]          Detect the project type and create .claude/prism.json
   prism init-global           Create ~/.claude/prism-config.json
+  prism preview [--scenario NAME] [--input FILE] [--watch]
+                              Render the status line from sample or recorded input
+  prism render [--debug]      Render status line JSON from stdin in-process;
+                              --debug prints per-section timings and errors
+  prism doctor                Check git, adb, credentials, hooks, plugins and config
+  prism logs [-f] [--level L] [--event NAME]
+                              Show the debug log (see log_level)
+  prism bench [-n N] [--cold] Measure render latency (p50/p95) on a fixture project
   prism update                Check for Prism updates and install
   prism check-update          Check for Prism updates (no install)
   prism version               Show version
   prism refract               Show available colors with prism animation
+  prism daemon [start|stop|status|run]
+                              Manage the background render daemon
+  prism config validate       Check config files for errors
+  prism config show [--json]  Show the merged config and where each value comes from
+  prism config schema         Print the config JSON Schema
+  prism config migrate [--dry-run]
+                              Rewrite config files that use deprecated keys
   prism help                  Show this help
 
+Global flags:
+  --set key=value             Override a config key, e.g. --set theme=light
+                              or --set plugins.android_devices.display=model
+  --verbose                   Warn about deprecated keys in config files
+
 Plugin commands:
   prism plugin list           List installed plugins with versions
   prism plugin add <url>      Install plugin from GitHub/URL
   prism plugin check-updates  Check plugins for updates
   prism plugin update <name>  Update a plugin (or --all)
   prism plugin remove <name>  Remove a plugin
+  prism plugin freeze [--prune]
+                              Pin installed plugins in .claude/prism-plugins.lock;
+                              --prune unlocks plugins not installed here
+  prism plugin sync [--prune] Install the plugins pinned in the lockfile;
+                              --prune also removes unpinned ones
 
 Config precedence (highest to lowest):
-  1. .claude/prism.local.json    Your personal overrides (gitignored)
-  2. .claude/prism.json          Repo config (commit for your team)
-  3. ~/.claude/prism-config.json Global defaults
+  1. --set key=value             Command-line overrides
+  2. PRISM_* variables           Environment overrides (e.g. PRISM_THEME=light)
+  3. .claude/prism.local.json    Your personal overrides (gitignored)
+  4. .claude/prism.json          Repo config (commit for your team)
+  5. ~/.claude/prism-config.json Global defaults
 `, version.Version)
 }
 
@@ -116,7 +245,12 @@ func handlePluginCommand(args []string) {
 		args = []string{"list"}
 	}
 
+	// Project trust settings can only tighten the global ones
+	projectDir, _ := os.Getwd()
 	pm := plugin.NewManager()
+	if trust := config.Load(projectDir).PluginTrust; trust != nil {
+		pm.SetTrust(plugin.Trust{RequireVerified: trust.RequireVerified, Keys: trust.Keys})
+	}
 
 	switch args[0] {
 	case "list", "ls":
@@ -141,6 +275,7 @@ func handlePluginCommand(args []string) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		lockHint(projectDir)
 
 	case "check-updates", "check":
 		pm.CheckUpdates()
@@ -154,6 +289,7 @@ func handlePluginCommand(args []string) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		lockHint(projectDir)
 
 	case "remove", "uninstall", "rm":
 		if len(args) < 2 {
@@ -164,6 +300,32 @@ func handlePluginCommand(args []string) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		lockHint(projectDir)
+
+	case "freeze":
+		prune := len(args) > 1 && args[1] == "--prune"
+		if err := pm.Freeze(plugin.LockPath(projectDir), prune); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "sync":
+		lockPath := plugin.LockPath(projectDir)
+		lock, err := plugin.ReadLock(lockPath)
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Error: no %s; run 'prism plugin freeze' to create one\n", lockPath)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		prune := len(args) > 1 && args[1] == "--prune"
+		fmt.Printf("Syncing plugins with %s...\n", lockPath)
+		if err := pm.Sync(lock, prune); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
 	default:
 		fmt.Printf("Unknown plugin command: %s\n", args[0])
@@ -172,16 +334,28 @@ func handlePluginCommand(args []string) {
 	}
 }
 
+// lockHint reminds the user to re-freeze after changing the plugins of a
+// project that has a lockfile
+func lockHint(projectDir string) {
+	if _, err := os.Stat(plugin.LockPath(projectDir)); err == nil {
+		fmt.Println("This project pins its plugins; run 'prism plugin freeze' to update .claude/prism-plugins.lock.")
+	}
+}
+
 func handleUpdate(autoMode bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
+	logging.Configure(config.Load("").LogLevel)
+	logging.Info("update", "auto", autoMode, "version", version.Version)
+
 	if !autoMode {
 		fmt.Println("Checking for updates...")
 	}
 
 	info, err := update.Check(ctx)
 	if err != nil {
+		logging.Error("update", "action", "check", "error", err)
 		if !autoMode {
 			fmt.Printf("Current version: %s\n", version.Version)
 			fmt.Fprintf(os.Stderr, "\nCannot update: %v\n", err)
@@ -195,6 +369,7 @@ func handleUpdate(autoMode bool) {
 	}
 
 	if !info.UpdateAvailable {
+		logging.Debug("update", "action", "none", "reason", "up to date", "latest", info.LatestVersion)
 		if !autoMode {
 			fmt.Println("\nYou're already on the latest version!")
 		}
@@ -206,12 +381,15 @@ func handleUpdate(autoMode bool) {
 	}
 
 	if err := update.Download(ctx); err != nil {
+		logging.Error("update", "action", "download", "version", info.LatestVersion, "error", err)
 		if !autoMode {
 			fmt.Fprintf(os.Stderr, "Error downloading update: %v\n", err)
 		}
 		os.Exit(1)
 	}
 
+	logging.Info("update", "action", "installed", "from", info.CurrentVersion, "to", info.LatestVersion)
+
 	// Clear the update cache so indicator disappears
 	cacheFile := filepath.Join(os.TempDir(), "prism-update-check")
 	os.Remove(cacheFile)
@@ -253,14 +431,6 @@ func handleCheckUpdate() {
 	}
 }
 
-func handleInit() {
-	if err := config.Init("."); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Println("Created .claude/prism.json")
-}
-
 func handleInitGlobal() {
 	if err := config.InitGlobal(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -272,40 +442,45 @@ func handleInitGlobal() {
 func handleHook(hookType string) {
 	// Read JSON from stdin (Claude Code provides session info)
 	var input hooks.Input
-	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+	decodeErr := json.NewDecoder(os.Stdin).Decode(&input)
+	if decodeErr != nil {
 		// Silent fail for hooks - don't break Claude Code
 		// Try to continue without session ID
 		input = hooks.Input{}
 	}
 
+	// Hook failures are not shown to the user, so the debug log is the
+	// only place they surface
+	logging.Configure(config.Load("").LogLevel)
+	if decodeErr != nil {
+		logging.Warn("hook", "hook", hookType, "error", fmt.Sprintf("bad input: %v", decodeErr))
+	}
+	logging.Info("hook", "hook", hookType, "session", input.SessionID)
+
 	manager := hooks.NewManager()
 
+	var err error
 	switch hookType {
 	case "idle":
-		if err := manager.HandleIdle(input); err != nil {
-			os.Exit(1)
-		}
+		err = manager.HandleIdle(input)
 	case "busy":
-		if err := manager.HandleBusy(input); err != nil {
-			os.Exit(1)
-		}
+		err = manager.HandleBusy(input)
 	case "session-start":
-		if err := manager.HandleSessionStart(input); err != nil {
-			os.Exit(1)
-		}
+		err = manager.HandleSessionStart(input)
 	case "session-end":
-		if err := manager.HandleSessionEnd(input); err != nil {
-			os.Exit(1)
-		}
+		err = manager.HandleSessionEnd(input)
 	case "pre-compact":
-		if err := manager.HandlePreCompact(input); err != nil {
-			os.Exit(1)
-		}
+		err = manager.HandlePreCompact(input)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown hook type: %s\n", hookType)
 		fmt.Fprintln(os.Stderr, "Available hooks: idle, busy, session-start, session-end, pre-compact")
 		os.Exit(1)
 	}
+
+	if err != nil {
+		logging.Error("hook", "hook", hookType, "session", input.SessionID, "error", err)
+		os.Exit(1)
+	}
 }
 
 func handleRefract() {
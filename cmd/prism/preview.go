@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/statusline"
+)
+
+const previewUsage = `Usage: prism preview [options]
+
+Render the status line with the current config, without Claude Code.
+
+Options:
+  --scenario NAME       Built-in sample session (default: default), see --list
+  --input FILE          Claude Code status line JSON to render ("-" for stdin)
+  --model NAME          Model display name
+  --context PCT         Context window used, in percent
+  --cost USD            Session cost
+  --lines-added N       Lines added this session
+  --lines-removed N     Lines removed this session
+  --dir PATH            Project directory (default: current directory)
+  --watch [SECONDS]     Re-render every SECONDS (default 1) until Ctrl-C,
+                        picking up config edits
+  --list                List the built-in scenarios`
+
+// previewOptions are the parsed `prism preview` flags
+type previewOptions struct {
+	scenario  string
+	inputFile string
+	dir       string
+	watch     time.Duration
+	list      bool
+	edits     []func(*statusline.Input) // Applied in flag order after the input is loaded
+}
+
+func handlePreview(args []string) {
+	opts, err := parsePreviewArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n%s\n", err, previewUsage)
+		os.Exit(1)
+	}
+
+	dir := opts.dir
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.list {
+		for _, s := range statusline.Scenarios(dir) {
+			fmt.Printf("  %-18s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+
+	scenario, ok := statusline.FindScenario(dir, opts.scenario)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown scenario %q (run 'prism preview --list')\n", opts.scenario)
+		os.Exit(1)
+	}
+
+	input := scenario.Input
+	if opts.inputFile != "" {
+		if input, err = readPreviewInput(opts.inputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if opts.dir != "" || input.Workspace.ProjectDir == "" {
+		input.Workspace = statusline.WorkspaceInfo{ProjectDir: dir, CurrentDir: dir}
+	}
+	for _, edit := range opts.edits {
+		edit(&input)
+	}
+
+	// One engine for every redraw; closing it stops the persistent plugins
+	// it started
+	engine := statusline.NewPreviewEngine(scenario.Usage)
+	defer engine.Close()
+	render := func() string {
+		cfg := config.LoadWorkspace(input.Workspace.ProjectDir, input.Workspace.CurrentDir)
+		sl := engine.New(input, cfg)
+		output := sl.Render()
+		sl.Wait()
+		return output
+	}
+
+	if opts.watch == 0 {
+		fmt.Println(render())
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(opts.watch)
+	defer ticker.Stop()
+	for {
+		// Clear the screen so multi-line status lines redraw in place
+		fmt.Print("\033[2J\033[H")
+		fmt.Println(render())
+		fmt.Printf("\n\033[2mScenario %s, refreshing every %s (Ctrl-C to stop)\033[0m\n", scenario.Name, opts.watch)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parsePreviewArgs parses the preview flags; each takes "--flag value" or
+// "--flag=value"
+func parsePreviewArgs(args []string) (previewOptions, error) {
+	opts := previewOptions{scenario: "default"}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "--list":
+			opts.list = true
+		case "--watch":
+			opts.watch = time.Second
+			// The interval is optional: "--watch", "--watch 2" or "--watch=0.5"
+			if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				value, hasValue = args[i+1], true
+				i++
+			}
+			if hasValue {
+				seconds, perr := strconv.ParseFloat(value, 64)
+				if perr != nil || seconds <= 0 {
+					return opts, fmt.Errorf("--watch needs a positive number of seconds, got %q", value)
+				}
+				opts.watch = time.Duration(seconds * float64(time.Second))
+			}
+		case "--scenario":
+			opts.scenario, err = next()
+		case "--input":
+			opts.inputFile, err = next()
+		case "--dir":
+			opts.dir, err = next()
+		case "--model":
+			var model string
+			if model, err = next(); err == nil {
+				opts.edits = append(opts.edits, func(in *statusline.Input) { in.Model.DisplayName = model })
+			}
+		case "--context":
+			var pct float64
+			if pct, err = previewNumber(name, next); err == nil {
+				if pct < 0 || pct > 100 {
+					return opts, fmt.Errorf("--context must be between 0 and 100")
+				}
+				opts.edits = append(opts.edits, func(in *statusline.Input) { in.SetContext(pct) })
+			}
+		case "--cost":
+			var cost float64
+			if cost, err = previewNumber(name, next); err == nil {
+				opts.edits = append(opts.edits, func(in *statusline.Input) { in.Cost.TotalCostUSD = cost })
+			}
+		case "--lines-added", "--lines-removed":
+			var n float64
+			if n, err = previewNumber(name, next); err == nil {
+				added := name == "--lines-added"
+				opts.edits = append(opts.edits, func(in *statusline.Input) {
+					if added {
+						in.Cost.TotalLinesAdded = int(n)
+					} else {
+						in.Cost.TotalLinesRemoved = int(n)
+					}
+				})
+			}
+		default:
+			return opts, fmt.Errorf("unknown preview option %s", args[i])
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// previewNumber reads a numeric flag value
+func previewNumber(name string, next func() (string, error)) (float64, error) {
+	value, err := next()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s needs a number, got %q", name, value)
+	}
+	return n, nil
+}
+
+// readPreviewInput reads recorded status line input from a file or stdin
+func readPreviewInput(path string) (statusline.Input, error) {
+	var input statusline.Input
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return input, err
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return input, fmt.Errorf("%s: invalid input JSON: %w", path, err)
+	}
+	return input, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/statusline"
+)
+
+// handleRender renders status line JSON in-process, bypassing the daemon.
+// With --debug it reports on stderr how each section rendered.
+func handleRender(args []string) {
+	debug := false
+	inputFile := "-"
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--debug":
+			debug = true
+		case "--input":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Usage: prism render [--debug] [--input FILE]")
+				os.Exit(1)
+			}
+			i++
+			inputFile = args[i]
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown render option: %s\n", args[i])
+			fmt.Fprintln(os.Stderr, "Usage: prism render [--debug] [--input FILE]")
+			os.Exit(1)
+		}
+	}
+
+	input, err := readPreviewInput(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	cfg := config.LoadWorkspace(input.Workspace.ProjectDir, input.Workspace.CurrentDir)
+	sl := statusline.New(input, cfg)
+	output := sl.Render()
+	elapsed := time.Since(start)
+
+	fmt.Println(output)
+	if !debug {
+		sl.Wait()
+		return
+	}
+
+	// Let plugins that missed the deadline finish so their errors show up
+	sl.Wait()
+	fmt.Fprintln(os.Stderr)
+	statusline.WriteStats(os.Stderr, sl.Stats())
+	fmt.Fprintf(os.Stderr, "\nRendered in %s\n", elapsed.Round(100*time.Microsecond))
+}
@@ -1,3 +1,8 @@
 module github.com/himattm/prism
 
 go 1.21
+
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	golang.org/x/sys v0.30.0
+)
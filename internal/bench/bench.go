@@ -0,0 +1,256 @@
+// Package bench measures render latency by replaying a recorded status line
+// input against a fixture git repo and a fake adb, for `prism bench` and the
+// package benchmarks
+package bench
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/statusline"
+)
+
+// recordedInput is a status line input captured from Claude Code
+//
+//go:embed input.json
+var recordedInput []byte
+
+// fakeADB answers the adb commands the android_devices plugin runs, with one
+// emulator that has the fixture app installed
+const fakeADB = `#!/bin/sh
+case "$*" in
+  devices) printf 'List of devices attached\nemulator-5554\tdevice\n' ;;
+  *"getprop ro.product.model"*) echo "Pixel 8" ;;
+  *getprop*) echo "14" ;;
+  *"pm list packages"*) echo "package:com.example.bench" ;;
+  *dumpsys*) echo "    versionName=1.2.3" ;;
+esac
+`
+
+// Fixture is a throwaway project: a git repo with uncommitted changes, and a
+// fake adb first on PATH until Close
+type Fixture struct {
+	Dir     string
+	oldPath string
+}
+
+// NewFixture creates the fixture project in a temp directory
+func NewFixture() (*Fixture, error) {
+	root, err := os.MkdirTemp("", "prism-bench-*")
+	if err != nil {
+		return nil, err
+	}
+	f := &Fixture{Dir: filepath.Join(root, "app"), oldPath: os.Getenv("PATH")}
+	if err := f.setup(root); err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+	os.Setenv("PATH", filepath.Join(root, "bin")+string(os.PathListSeparator)+f.oldPath)
+	return f, nil
+}
+
+func (f *Fixture) setup(root string) error {
+	bin := filepath.Join(root, "bin")
+	for _, dir := range []string{bin, filepath.Join(f.Dir, "src")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(bin, "adb"), []byte(fakeADB), 0755); err != nil {
+		return err
+	}
+
+	git := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = f.Dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %v: %w: %s", args, err, out)
+		}
+		return nil
+	}
+	if err := git("init", "-q", "-b", "main"); err != nil {
+		return err
+	}
+	git("config", "user.email", "bench@example.com")
+	git("config", "user.name", "Bench")
+
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(f.Dir, "src", fmt.Sprintf("file%02d.go", i))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("package src\n\nconst N%d = %d\n", i, i)), 0644); err != nil {
+			return err
+		}
+	}
+	if err := git("add", "."); err != nil {
+		return err
+	}
+	if err := git("commit", "-q", "-m", "Initial commit"); err != nil {
+		return err
+	}
+
+	// Uncommitted and untracked changes for git and linesChanged to report
+	os.WriteFile(filepath.Join(f.Dir, "src", "file00.go"), []byte("package src\n\nconst N0 = 100\nconst M0 = 1\n"), 0644)
+	os.WriteFile(filepath.Join(f.Dir, "src", "new.go"), []byte("package src\n"), 0644)
+	return nil
+}
+
+// Close restores PATH and removes the fixture
+func (f *Fixture) Close() {
+	os.Setenv("PATH", f.oldPath)
+	os.RemoveAll(filepath.Dir(f.Dir))
+}
+
+// Input returns the recorded input, pointed at the fixture project
+func (f *Fixture) Input() statusline.Input {
+	var input statusline.Input
+	json.Unmarshal(recordedInput, &input)
+	input.Workspace = statusline.WorkspaceInfo{ProjectDir: f.Dir, CurrentDir: f.Dir}
+	return input
+}
+
+// Config returns the config rendered by the benchmarks: every built-in
+// section, with the update check (which needs the network) turned off
+func Config() config.Config {
+	return config.Config{
+		Icon: "💎",
+		Sections: []any{
+			[]any{"dir", "model", "context", "linesChanged", "usage", "git"},
+			[]any{"android_devices"},
+		},
+		Plugins: map[string]any{
+			"update": map[string]any{"enabled": false},
+			"android_devices": map[string]any{
+				"display":  "model",
+				"packages": []any{"com.example.bench"},
+			},
+		},
+	}
+}
+
+// Options configures Run
+type Options struct {
+	Iterations int
+	Cold       bool // New plugin caches for every render, like a one-shot process; otherwise warm, like the daemon
+	Input      statusline.Input
+	Config     config.Config
+}
+
+// Latency summarizes the durations of many renders
+type Latency struct {
+	P50, P95, Max time.Duration
+}
+
+// Summarize returns the percentiles of durations (nearest rank)
+func Summarize(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) time.Duration {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return sorted[idx]
+	}
+	return Latency{P50: rank(0.50), P95: rank(0.95), Max: sorted[len(sorted)-1]}
+}
+
+// SectionLatency is the latency of one section across renders
+type SectionLatency struct {
+	Name string
+	Latency
+}
+
+// Report is the outcome of Run
+type Report struct {
+	Iterations int
+	Cold       bool
+	Budget     time.Duration
+	Total      Latency
+	Sections   []SectionLatency // In the order they were first rendered
+	OverBudget int              // Renders that left out or staled a section to meet the budget
+}
+
+// Run renders opts.Input opts.Iterations times and reports the latencies
+func Run(opts Options) Report {
+	if opts.Iterations <= 0 {
+		opts.Iterations = 100
+	}
+	report := Report{Iterations: opts.Iterations, Cold: opts.Cold, Budget: opts.Config.GetRenderBudget()}
+
+	var totals []time.Duration
+	perSection := make(map[string][]time.Duration)
+	var order []string
+
+	engine := statusline.NewPreviewEngine(nil)
+	defer func() { engine.Close() }()
+	for i := 0; i < opts.Iterations; i++ {
+		if opts.Cold {
+			engine.Close()
+			engine = statusline.NewPreviewEngine(nil)
+		}
+		sl := engine.New(opts.Input, opts.Config)
+
+		start := time.Now()
+		sl.Render()
+		totals = append(totals, time.Since(start))
+		sl.Wait()
+
+		over := false
+		for _, s := range sl.Stats() {
+			if s.Duration == 0 {
+				continue // Hidden by a condition
+			}
+			if _, seen := perSection[s.Name]; !seen {
+				order = append(order, s.Name)
+			}
+			perSection[s.Name] = append(perSection[s.Name], s.Duration)
+			over = over || s.TimedOut
+		}
+		if over {
+			report.OverBudget++
+		}
+	}
+
+	report.Total = Summarize(totals)
+	for _, name := range order {
+		report.Sections = append(report.Sections, SectionLatency{Name: name, Latency: Summarize(perSection[name])})
+	}
+	return report
+}
+
+// Write prints the report as a table
+func (r Report) Write(w io.Writer) {
+	mode := "warm"
+	if r.Cold {
+		mode = "cold"
+	}
+	fmt.Fprintf(w, "%d renders, %s caches, budget %s\n\n", r.Iterations, mode, r.Budget)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECTION\tP50\tP95\tMAX")
+	for _, s := range r.Sections {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, formatDuration(s.P50), formatDuration(s.P95), formatDuration(s.Max))
+	}
+	fmt.Fprintf(tw, "total\t%s\t%s\t%s\n", formatDuration(r.Total.P50), formatDuration(r.Total.P95), formatDuration(r.Total.Max))
+	tw.Flush()
+
+	if r.OverBudget > 0 {
+		fmt.Fprintf(w, "\n%d of %d renders hit the budget and left sections out\n", r.OverBudget, r.Iterations)
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,123 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/himattm/prism/internal/statusline"
+)
+
+func TestSummarize(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	got := Summarize(durations)
+	want := Latency{P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, Max: 100 * time.Millisecond}
+	if got != want {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+	if durations[0] != 100*time.Millisecond {
+		t.Error("Summarize sorted its argument in place")
+	}
+	if got := Summarize(nil); got != (Latency{}) {
+		t.Errorf("Summarize(nil) = %+v, want zero", got)
+	}
+}
+
+func TestFixture_RendersEverySection(t *testing.T) {
+	f := newFixture(t)
+
+	sl := statusline.NewPreviewEngine(nil).New(f.Input(), Config())
+	output := sl.Render()
+	sl.Wait()
+
+	for _, want := range []string{"app", "Opus 4.5", "49%", "main", "Pixel 8", "$2.41"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("render missing %q:\n%s", want, output)
+		}
+	}
+	for _, s := range sl.Stats() {
+		if s.Err != nil && s.Hidden == "" {
+			t.Errorf("section %s failed: %v", s.Name, s.Err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	f := newFixture(t)
+
+	report := Run(Options{Iterations: 5, Input: f.Input(), Config: Config()})
+	if report.Total.P50 <= 0 || report.Total.P95 < report.Total.P50 {
+		t.Errorf("total latency = %+v", report.Total)
+	}
+	// Guards the README's "Fast": the fixture renders well inside the default budget
+	if report.OverBudget > 0 {
+		t.Errorf("%d of %d renders hit the %s budget", report.OverBudget, report.Iterations, report.Budget)
+	}
+	names := map[string]bool{}
+	for _, s := range report.Sections {
+		names[s.Name] = true
+	}
+	for _, want := range []string{"git", "android_devices", "context"} {
+		if !names[want] {
+			t.Errorf("report has no %s section: %+v", want, report.Sections)
+		}
+	}
+
+	var b strings.Builder
+	report.Write(&b)
+	if !strings.Contains(b.String(), "total") {
+		t.Errorf("report table missing total row:\n%s", b.String())
+	}
+}
+
+// The benchmarks report p50/p95 render latency alongside ns/op:
+//
+//	go test -bench . ./internal/bench
+func BenchmarkRenderWarm(b *testing.B) { benchmarkRender(b, false) }
+
+func BenchmarkRenderCold(b *testing.B) { benchmarkRender(b, true) }
+
+func benchmarkRender(b *testing.B, cold bool) {
+	f, err := NewFixture()
+	if err != nil {
+		b.Skipf("fixture: %v", err)
+	}
+	defer f.Close()
+
+	input, cfg := f.Input(), Config()
+	engine := statusline.NewPreviewEngine(nil)
+	durations := make([]time.Duration, 0, b.N)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if cold {
+			engine = statusline.NewPreviewEngine(nil)
+		}
+		sl := engine.New(input, cfg)
+		start := time.Now()
+		sl.Render()
+		durations = append(durations, time.Since(start))
+		b.StopTimer()
+		sl.Wait()
+		b.StartTimer()
+	}
+	b.StopTimer()
+
+	latency := Summarize(durations)
+	b.ReportMetric(float64(latency.P50)/float64(time.Millisecond), "p50-ms")
+	b.ReportMetric(float64(latency.P95)/float64(time.Millisecond), "p95-ms")
+}
+
+func newFixture(t *testing.T) *Fixture {
+	t.Helper()
+	f, err := NewFixture()
+	if err != nil {
+		t.Skipf("fixture: %v", err)
+	}
+	t.Cleanup(f.Close)
+	return f
+}
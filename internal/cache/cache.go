@@ -1,14 +1,27 @@
 package cache
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/himattm/prism/internal/logging"
 )
 
-// Cache provides thread-safe in-memory caching with TTL
+// Cache provides thread-safe in-memory caching with TTL.
+// A cache created with NewPersistent is additionally backed by a file so
+// results are shared between prism invocations (see Flush).
 type Cache struct {
 	mu    sync.RWMutex
 	items map[string]cacheItem
+
+	path      string          // Backing file ("" = memory only)
+	dirty     map[string]bool // Keys set or deleted since the last flush
+	transient map[string]bool // Keys that are never written to disk
 }
 
 type cacheItem struct {
@@ -16,6 +29,12 @@ type cacheItem struct {
 	expiresAt time.Time
 }
 
+// diskItem is the on-disk representation of a cache entry
+type diskItem struct {
+	Value     string `json:"v"`
+	ExpiresAt int64  `json:"e"` // Unix nanoseconds
+}
+
 // New creates a new cache instance
 func New() *Cache {
 	return &Cache{
@@ -23,6 +42,66 @@ func New() *Cache {
 	}
 }
 
+// NewPersistent creates a cache backed by the given file.
+// Unexpired entries are loaded immediately; changes are written back by Flush.
+// If the file's directory is not private to this user (see PrivateDir), the
+// cache is memory-only, so no one else can feed it entries.
+func NewPersistent(path string) *Cache {
+	c := New()
+	if err := PrivateDir(filepath.Dir(path)); err != nil {
+		logging.Warn("cache", "path", path, "error", err)
+		return c
+	}
+	c.path = path
+	c.dirty = make(map[string]bool)
+
+	if items, err := readFile(path); err == nil {
+		now := time.Now()
+		for key, item := range items {
+			expiresAt := time.Unix(0, item.ExpiresAt)
+			if now.Before(expiresAt) {
+				c.items[key] = cacheItem{value: item.Value, expiresAt: expiresAt}
+			}
+		}
+	}
+
+	return c
+}
+
+// Dir returns the per-user directory holding persistent cache files
+func Dir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("prism-cache-%d", os.Getuid()))
+}
+
+// PrivateDir creates dir if needed and checks that only the current user
+// can use it: a real directory (not a symlink), owned by this user, with no
+// access for anyone else. In a shared temp dir, anyone could otherwise
+// create it first.
+func PrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by uid %d, not by you (uid %d)", dir, st.Uid, os.Getuid())
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %o); run 'chmod 700 %s'", dir, info.Mode().Perm(), dir)
+	}
+	return nil
+}
+
+// Path returns the backing file path for a named persistent cache
+func Path(name string) string {
+	return filepath.Join(Dir(), name+".json")
+}
+
 // Get retrieves a value from the cache
 // Returns the value and true if found and not expired, empty string and false otherwise
 func (c *Cache) Get(key string) (string, bool) {
@@ -50,6 +129,7 @@ func (c *Cache) Set(key, value string, ttl time.Duration) {
 		value:     value,
 		expiresAt: time.Now().Add(ttl),
 	}
+	c.markDirty(key)
 }
 
 // IsStale returns true if the key is missing or expired
@@ -70,6 +150,7 @@ func (c *Cache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.items, key)
+	c.markDirty(key)
 }
 
 // DeleteByPrefix removes all keys with the given prefix
@@ -77,8 +158,19 @@ func (c *Cache) DeleteByPrefix(prefix string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	for key := range c.items {
-		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+		if hasPrefix(key, prefix) {
 			delete(c.items, key)
+			c.markDirty(key)
+		}
+	}
+	// Entries written by other processes since we loaded must go too
+	if c.path != "" {
+		if items, err := readFile(c.path); err == nil {
+			for key := range items {
+				if hasPrefix(key, prefix) {
+					c.markDirty(key)
+				}
+			}
 		}
 	}
 }
@@ -87,9 +179,152 @@ func (c *Cache) DeleteByPrefix(prefix string) {
 func (c *Cache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	for key := range c.items {
+		c.markDirty(key)
+	}
 	c.items = make(map[string]cacheItem)
 }
 
+// Transient marks keys that must never be written to disk (secrets)
+func (c *Cache) Transient(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.transient == nil {
+		c.transient = make(map[string]bool)
+	}
+	for _, key := range keys {
+		c.transient[key] = true
+		delete(c.dirty, key)
+	}
+}
+
+// Flush writes changes made since the last flush to the backing file.
+// The file is re-read under an exclusive lock and only our own changes are
+// applied on top, so concurrent prism processes don't clobber each other.
+// It is a no-op for memory-only caches.
+func (c *Cache) Flush() error {
+	c.mu.Lock()
+	if c.path == "" || len(c.dirty) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	changes := make(map[string]*cacheItem, len(c.dirty))
+	for key := range c.dirty {
+		if item, ok := c.items[key]; ok {
+			changes[key] = &item
+		} else {
+			changes[key] = nil // Deleted
+		}
+	}
+	c.dirty = make(map[string]bool)
+	c.mu.Unlock()
+
+	// Checked again: a temp cleaner may have removed the directory since
+	if err := PrivateDir(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(c.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	items, err := readFile(c.path)
+	if err != nil {
+		items = make(map[string]diskItem)
+	}
+
+	for key, item := range changes {
+		if item == nil {
+			delete(items, key)
+			continue
+		}
+		items[key] = diskItem{Value: item.value, ExpiresAt: item.expiresAt.UnixNano()}
+	}
+
+	// Drop expired entries so the file doesn't grow forever
+	now := time.Now().UnixNano()
+	for key, item := range items {
+		if item.ExpiresAt <= now {
+			delete(items, key)
+		}
+	}
+
+	return writeFileAtomic(c.path, items)
+}
+
+func (c *Cache) markDirty(key string) {
+	if c.path != "" && !c.transient[key] {
+		c.dirty[key] = true
+	}
+}
+
+func hasPrefix(key, prefix string) bool {
+	return len(key) >= len(prefix) && key[:len(prefix)] == prefix
+}
+
+func readFile(path string) (map[string]diskItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var items map[string]diskItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = make(map[string]diskItem)
+	}
+	return items, nil
+}
+
+// writeFileAtomic writes via a temp file + rename so readers never see a partial file
+func writeFileAtomic(path string, items map[string]diskItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// lockFile takes an exclusive advisory lock, returning a function that releases it
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
 // Common TTL constants
 const (
 	GitTTL      = 2 * time.Second
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func tempCachePath(t *testing.T) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "prism-cache-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+	return filepath.Join(tmpDir, "test.json")
+}
+
+func TestPersistent_SurvivesNewInstance(t *testing.T) {
+	path := tempCachePath(t)
+
+	c1 := NewPersistent(path)
+	c1.Set("git:/repo", "main*", time.Minute)
+	if err := c1.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	c2 := NewPersistent(path)
+	if v, ok := c2.Get("git:/repo"); !ok || v != "main*" {
+		t.Errorf("expected persisted value 'main*', got %q (found=%v)", v, ok)
+	}
+}
+
+func TestPersistent_ExpiredEntriesNotLoaded(t *testing.T) {
+	path := tempCachePath(t)
+
+	c1 := NewPersistent(path)
+	c1.Set("short", "x", 10*time.Millisecond)
+	c1.Flush()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c2 := NewPersistent(path)
+	if _, ok := c2.Get("short"); ok {
+		t.Error("expired entry should not be loaded")
+	}
+}
+
+func TestPersistent_ConcurrentWritersMerge(t *testing.T) {
+	path := tempCachePath(t)
+
+	// Two processes load the same (empty) file, then each writes its own key
+	a := NewPersistent(path)
+	b := NewPersistent(path)
+	a.Set("a", "1", time.Minute)
+	b.Set("b", "2", time.Minute)
+	a.Flush()
+	b.Flush()
+
+	c := NewPersistent(path)
+	if _, ok := c.Get("a"); !ok {
+		t.Error("key from first writer was lost")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("key from second writer was lost")
+	}
+}
+
+func TestPersistent_DeleteByPrefixPropagates(t *testing.T) {
+	path := tempCachePath(t)
+
+	writer := NewPersistent(path)
+	writer.Set("git:/a", "main", time.Minute)
+	writer.Set("android:serial", "emulator", time.Minute)
+
+	// Invalidator loaded before the writer flushed, like a hook process would
+	invalidator := NewPersistent(path)
+	writer.Flush()
+
+	invalidator.DeleteByPrefix("git:")
+	invalidator.Flush()
+
+	c := NewPersistent(path)
+	if _, ok := c.Get("git:/a"); ok {
+		t.Error("git entry should have been deleted")
+	}
+	if _, ok := c.Get("android:serial"); !ok {
+		t.Error("unrelated entry should survive")
+	}
+}
+
+func TestPersistent_TransientKeysStayInMemory(t *testing.T) {
+	path := tempCachePath(t)
+
+	c1 := NewPersistent(path)
+	c1.Transient("secret")
+	c1.Set("secret", "token", time.Minute)
+	c1.Set("public", "value", time.Minute)
+	c1.Flush()
+
+	if v, ok := c1.Get("secret"); !ok || v != "token" {
+		t.Error("transient key should still be readable in memory")
+	}
+
+	c2 := NewPersistent(path)
+	if _, ok := c2.Get("secret"); ok {
+		t.Error("transient key must not be written to disk")
+	}
+	if _, ok := c2.Get("public"); !ok {
+		t.Error("regular key should be persisted")
+	}
+}
+
+func TestFlush_MemoryOnlyIsNoop(t *testing.T) {
+	c := New()
+	c.Set("k", "v", time.Minute)
+	if err := c.Flush(); err != nil {
+		t.Errorf("flush on memory cache should be a no-op, got %v", err)
+	}
+}
+
+func TestPrivateDir(t *testing.T) {
+	base := t.TempDir()
+
+	dir := filepath.Join(base, "new")
+	if err := PrivateDir(dir); err != nil {
+		t.Fatalf("new directory refused: %v", err)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("created with mode %o, want 700", info.Mode().Perm())
+	}
+
+	shared := filepath.Join(base, "shared")
+	os.Mkdir(shared, 0755)
+	os.Chmod(shared, 0777)
+	if err := PrivateDir(shared); err == nil {
+		t.Error("expected a directory others can write to be refused")
+	}
+
+	link := filepath.Join(base, "link")
+	os.Symlink(dir, link)
+	if err := PrivateDir(link); err == nil {
+		t.Error("expected a symlink to be refused")
+	}
+}
+
+func TestPersistent_IgnoresSharedDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shared")
+	os.Mkdir(dir, 0777)
+	os.Chmod(dir, 0777)
+	path := filepath.Join(dir, "test.json")
+	os.WriteFile(path, []byte(`{"oauth_token": {"v": "planted", "e": 4102444800000000000}}`), 0644)
+
+	c := NewPersistent(path)
+	if v, ok := c.Get("oauth_token"); ok {
+		t.Errorf("read %q from a directory other users can write", v)
+	}
+	c.Set("key", "value", time.Minute)
+	c.Flush()
+	if data, _ := os.ReadFile(path); string(data) != `{"oauth_token": {"v": "planted", "e": 4102444800000000000}}` {
+		t.Errorf("wrote to a directory other users can write: %s", data)
+	}
+}
@@ -0,0 +1,229 @@
+package colors
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Level is how many colors the output may use
+type Level int
+
+const (
+	LevelNone      Level = iota // No escape codes at all
+	Level16                     // Basic + bright ANSI colors
+	Level256                    // xterm 256-color palette
+	LevelTrueColor              // 24-bit RGB
+)
+
+// ColorModes lists the accepted values for the colorMode config key
+var ColorModes = []string{"auto", "none", "16", "256", "truecolor"}
+
+// EnvVars are the environment variables DetectLevel reads
+var EnvVars = []string{"NO_COLOR", "COLORTERM", "TERM"}
+
+// DetectLevel resolves a colorMode config value to a Level.
+// "auto" (or "") honors NO_COLOR (https://no-color.org), COLORTERM and TERM.
+// Without any hint it assumes 256 colors, which Claude Code renders.
+func DetectLevel(mode string) Level {
+	return DetectLevelEnv(mode, os.Getenv)
+}
+
+// DetectLevelEnv is DetectLevel for another process's environment, such as
+// the terminal a daemon renders for
+func DetectLevelEnv(mode string, getenv func(string) string) Level {
+	switch mode {
+	case "none":
+		return LevelNone
+	case "16":
+		return Level16
+	case "256":
+		return Level256
+	case "truecolor":
+		return LevelTrueColor
+	}
+
+	if getenv("NO_COLOR") != "" {
+		return LevelNone
+	}
+
+	colorTerm := strings.ToLower(getenv("COLORTERM"))
+	if colorTerm == "truecolor" || colorTerm == "24bit" {
+		return LevelTrueColor
+	}
+
+	term := getenv("TERM")
+	switch {
+	case term == "dumb":
+		return LevelNone
+	case strings.Contains(term, "256color"):
+		return Level256
+	}
+
+	return Level256
+}
+
+// sgrPattern matches SGR (color/style) escape sequences
+var sgrPattern = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// Downgrade rewrites color codes in text so they fit the given level:
+// truecolor becomes the nearest 256 or 16 color, 256 colors become the
+// nearest 16 color, and LevelNone strips every escape sequence.
+func Downgrade(text string, level Level) string {
+	switch level {
+	case LevelTrueColor:
+		return text
+	case LevelNone:
+		return Strip(text)
+	}
+
+	return sgrPattern.ReplaceAllStringFunc(text, func(seq string) string {
+		params := strings.Split(sgrPattern.FindStringSubmatch(seq)[1], ";")
+		return "\x1b[" + strings.Join(downgradeParams(params, level), ";") + "m"
+	})
+}
+
+func downgradeParams(params []string, level Level) []string {
+	var out []string
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		if (p != "38" && p != "48") || i+1 >= len(params) {
+			out = append(out, p)
+			continue
+		}
+		background := p == "48"
+
+		switch params[i+1] {
+		case "5": // 38;5;N
+			if i+2 >= len(params) {
+				out = append(out, params[i:]...)
+				return out
+			}
+			n, _ := strconv.Atoi(params[i+2])
+			i += 2
+			if level == Level256 {
+				out = append(out, p, "5", strconv.Itoa(n))
+				continue
+			}
+			r, g, b := rgbOf256(n)
+			out = append(out, basicCode(nearest16(r, g, b), background))
+
+		case "2": // 38;2;R;G;B
+			if i+4 >= len(params) {
+				out = append(out, params[i:]...)
+				return out
+			}
+			r, _ := strconv.Atoi(params[i+2])
+			g, _ := strconv.Atoi(params[i+3])
+			b, _ := strconv.Atoi(params[i+4])
+			i += 4
+			if level == Level256 {
+				out = append(out, p, "5", strconv.Itoa(nearest256(r, g, b)))
+				continue
+			}
+			out = append(out, basicCode(nearest16(r, g, b), background))
+
+		default:
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// basic16 holds the xterm default RGB values of the 16 basic colors
+var basic16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube (indices 16-231)
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func rgbOf256(n int) (int, int, int) {
+	switch {
+	case n < 0 || n > 255:
+		return 0, 0, 0
+	case n < 16:
+		c := basic16[n]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[(n/6)%6], cubeLevels[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+func nearest16(r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range basic16 {
+		d := colorDistance(r, g, b, c[0], c[1], c[2])
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func nearest256(r, g, b int) int {
+	// Closest point in the color cube
+	cube := func(v int) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if abs(v-l) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := cube(r), cube(g), cube(b)
+	cubeIndex := 16 + 36*ri + 6*gi + bi
+	cubeDist := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// Closest grayscale ramp entry
+	avg := (r + g + b) / 3
+	grayIndex := 232
+	if avg > 8 {
+		grayIndex = 232 + (avg-8)/10
+		if grayIndex > 255 {
+			grayIndex = 255
+		}
+	}
+	gv := 8 + (grayIndex-232)*10
+	grayDist := colorDistance(r, g, b, gv, gv, gv)
+
+	if grayDist < cubeDist {
+		return grayIndex
+	}
+	return cubeIndex
+}
+
+// basicCode returns the SGR parameter for one of the 16 basic colors
+func basicCode(n int, background bool) string {
+	base := 30
+	if n >= 8 {
+		base = 90
+		n -= 8
+	}
+	if background {
+		base += 10
+	}
+	return fmt.Sprintf("%d", base+n)
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package colors
+
+import "testing"
+
+func TestDetectLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		env      map[string]string
+		expected Level
+	}{
+		{"no_color", "auto", map[string]string{"NO_COLOR": "1"}, LevelNone},
+		{"truecolor", "", map[string]string{"COLORTERM": "truecolor"}, LevelTrueColor},
+		{"dumb", "", map[string]string{"TERM": "dumb"}, LevelNone},
+		{"256color", "", map[string]string{"TERM": "xterm-256color"}, Level256},
+		{"default", "", nil, Level256},
+		{"override wins", "16", map[string]string{"COLORTERM": "truecolor"}, Level16},
+		{"override none", "none", nil, LevelNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "COLORTERM", "TERM"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := DetectLevel(tt.mode); got != tt.expected {
+				t.Errorf("DetectLevel(%q) = %d, want %d", tt.mode, got, tt.expected)
+			}
+			// The same, from a forwarded environment instead of our own
+			t.Setenv("COLORTERM", "")
+			t.Setenv("TERM", "dumb")
+			if got := DetectLevelEnv(tt.mode, func(key string) string { return tt.env[key] }); got != tt.expected {
+				t.Errorf("DetectLevelEnv(%q) = %d, want %d", tt.mode, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDowngrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		level    Level
+		expected string
+	}{
+		{"truecolor untouched", "\033[38;2;255;128;0mx", LevelTrueColor, "\033[38;2;255;128;0mx"},
+		{"none strips", Yellow + "x" + Reset, LevelNone, "x"},
+		{"truecolor to 256", "\033[38;2;255;0;0mx", Level256, "\033[38;5;196mx"},
+		{"truecolor gray to 256", "\033[38;2;128;128;128mx", Level256, "\033[38;5;244mx"},
+		{"256 kept at 256", "\033[38;5;214mx", Level256, "\033[38;5;214mx"},
+		{"256 to 16", "\033[38;5;196mx", Level16, "\033[91mx"},
+		{"background to 16", "\033[48;5;21mx", Level16, "\033[44mx"},
+		{"truecolor to 16", "\033[1;38;2;0;205;0mx", Level16, "\033[1;32mx"},
+		{"basic codes kept", Dim + "x" + Reset, Level16, Dim + "x" + Reset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Downgrade(tt.input, tt.level); got != tt.expected {
+				t.Errorf("Downgrade(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package colors
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // ANSI color codes - A full spectrum for Prism
 const (
@@ -228,3 +232,31 @@ func Wrap(color, text string) string {
 func Separator() string {
 	return fmt.Sprintf(" %s·%s ", Dim, Reset)
 }
+
+// ansiPattern matches ANSI escape sequences (SGR colors and other CSI codes)
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// Strip removes all ANSI escape sequences from text
+func Strip(text string) string {
+	return ansiPattern.ReplaceAllString(text, "")
+}
+
+// Background converts a foreground color code to the matching background code
+// (e.g. "\033[33m" -> "\033[43m", "\033[38;5;214m" -> "\033[48;5;214m").
+// Codes that aren't foreground colors are returned unchanged.
+func Background(code string) string {
+	if !strings.HasPrefix(code, "\033[") || !strings.HasSuffix(code, "m") {
+		return code
+	}
+	params := strings.TrimSuffix(strings.TrimPrefix(code, "\033["), "m")
+
+	switch {
+	case strings.HasPrefix(params, "38;"):
+		return "\033[48;" + strings.TrimPrefix(params, "38;") + "m"
+	case len(params) == 2 && params[0] == '3' && params[1] >= '0' && params[1] <= '7':
+		return "\033[4" + params[1:] + "m"
+	case len(params) == 2 && params[0] == '9' && params[1] >= '0' && params[1] <= '7':
+		return "\033[10" + params[1:] + "m"
+	}
+	return code
+}
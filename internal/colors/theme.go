@@ -0,0 +1,178 @@
+package colors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Semantic roles used by built-in sections and plugins. Themes and the user's
+// "colors" config remap these instead of hardcoding yellow/red everywhere.
+var roleDefaults = map[string]string{
+	"warning":  "yellow",  // Approaching a limit (context 70%+, usage 70%+)
+	"critical": "red",     // At or over a limit (90%+)
+	"branch":   "yellow",  // Git branch
+	"device":   "emerald", // Android devices
+	"dir":      "cyan",    // Project directory
+	"model":    "magenta", // Model name
+	"added":    "green",   // Lines added
+	"removed":  "red",     // Lines removed
+	"muted":    "gray",    // Secondary text such as cost
+}
+
+// theme overrides palette entries. Values use the same syntax as user colors
+// (color names, 256-color indices or #rrggbb) and may set roles or base colors.
+type theme map[string]string
+
+var themes = map[string]theme{
+	// dark is the original Prism palette
+	"dark": {},
+
+	// light swaps pale colors for darker shades readable on white backgrounds
+	"light": {
+		"yellow":  "136",
+		"cyan":    "31",
+		"green":   "28",
+		"magenta": "127",
+		"red":     "160",
+		"gray":    "242",
+		"white":   "235",
+		"emerald": "29",
+		"warning": "166",
+	},
+
+	// solarized uses the Solarized accent colors
+	"solarized": {
+		"yellow":   "136",
+		"orange":   "166",
+		"red":      "160",
+		"magenta":  "125",
+		"violet":   "61",
+		"blue":     "33",
+		"cyan":     "37",
+		"green":    "64",
+		"gray":     "240",
+		"emerald":  "64",
+		"warning":  "yellow",
+		"critical": "red",
+		"model":    "violet",
+		"muted":    "gray",
+	},
+
+	// high-contrast uses bold bright variants
+	"high-contrast": {
+		"warning":  "\033[1;93m",
+		"critical": "\033[1;91m",
+		"branch":   "bright_yellow",
+		"device":   "bright_green",
+		"dir":      "bright_cyan",
+		"model":    "bright_magenta",
+		"added":    "bright_green",
+		"removed":  "bright_red",
+		"muted":    "white",
+	},
+
+	// colorblind-safe uses the Okabe-Ito palette and avoids red/green pairs
+	"colorblind-safe": {
+		"warning":  "#E69F00",
+		"critical": "#D55E00",
+		"branch":   "#F0E442",
+		"device":   "#009E73",
+		"dir":      "#56B4E9",
+		"model":    "#CC79A7",
+		"added":    "#0072B2",
+		"removed":  "#D55E00",
+	},
+}
+
+// Themes returns the names of all built-in themes
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRole returns true if name is a semantic color role
+func IsRole(name string) bool {
+	_, ok := roleDefaults[name]
+	return ok
+}
+
+// Palette builds the color map handed to sections and plugins: every color
+// from ColorMap plus the semantic roles, with the theme and then the user's
+// overrides applied. Unknown themes fall back to "dark"; invalid override
+// values are ignored.
+func Palette(themeName string, overrides map[string]string) map[string]string {
+	palette := ColorMap()
+
+	t, ok := themes[themeName]
+	if !ok {
+		t = themes["dark"]
+	}
+
+	// Base colors first, so roles that reference them pick up the change
+	roles := make(map[string]string, len(roleDefaults))
+	for role, value := range roleDefaults {
+		roles[role] = value
+	}
+	for _, layer := range []map[string]string{t, overrides} {
+		for name, value := range layer {
+			if IsRole(name) {
+				roles[name] = value
+				continue
+			}
+			if code, ok := ParseColor(value, palette); ok {
+				palette[name] = code
+			}
+		}
+	}
+
+	for role, value := range roles {
+		if code, ok := ParseColor(value, palette); ok {
+			palette[role] = code
+		} else {
+			palette[role] = palette[roleDefaults[role]]
+		}
+	}
+
+	return palette
+}
+
+// ParseColor resolves a color value to an ANSI escape code. Accepted forms:
+//
+//	"yellow"       a name from the palette
+//	"214"          a 256-color index
+//	"#ffaa00"      a 24-bit truecolor hex value
+//	"\033[1;33m"   a raw escape sequence
+func ParseColor(value string, palette map[string]string) (string, bool) {
+	value = strings.TrimSpace(value)
+
+	if code, ok := palette[value]; ok {
+		return code, true
+	}
+
+	if strings.HasPrefix(value, "\033[") {
+		return value, true
+	}
+
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 || n > 255 {
+			return "", false
+		}
+		return fmt.Sprintf("\033[38;5;%dm", n), true
+	}
+
+	if strings.HasPrefix(value, "#") && len(value) == 7 {
+		rgb, err := strconv.ParseUint(value[1:], 16, 32)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", rgb>>16&0xff, rgb>>8&0xff, rgb&0xff), true
+	}
+
+	return "", false
+}
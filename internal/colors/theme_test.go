@@ -0,0 +1,71 @@
+package colors
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	palette := ColorMap()
+
+	tests := []struct {
+		value    string
+		expected string
+		ok       bool
+	}{
+		{"yellow", Yellow, true},
+		{"214", "\033[38;5;214m", true},
+		{"#ff8000", "\033[38;2;255;128;0m", true},
+		{"\033[1;33m", "\033[1;33m", true},
+		{"256", "", false},
+		{"#zzzzzz", "", false},
+		{"not_a_color", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseColor(tt.value, palette)
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("ParseColor(%q) = %q, %v; want %q, %v", tt.value, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestPalette_DefaultRoles(t *testing.T) {
+	p := Palette("", nil)
+
+	if p["warning"] != Yellow || p["critical"] != Red || p["device"] != Emerald {
+		t.Errorf("default roles should match the original colors, got warning=%q critical=%q device=%q",
+			p["warning"], p["critical"], p["device"])
+	}
+	if p["reset"] != Reset {
+		t.Error("palette should include all base colors")
+	}
+}
+
+func TestPalette_ThemeAndOverrides(t *testing.T) {
+	p := Palette("light", map[string]string{
+		"branch":   "#00ff00",
+		"critical": "bright_red",
+		"cyan":     "39",
+		"device":   "not_a_color",
+	})
+
+	if p["yellow"] != "\033[38;5;136m" {
+		t.Errorf("light theme should darken yellow, got %q", p["yellow"])
+	}
+	if p["branch"] != "\033[38;2;0;255;0m" {
+		t.Errorf("role override should accept hex, got %q", p["branch"])
+	}
+	if p["critical"] != BrightRed {
+		t.Errorf("role override should accept names, got %q", p["critical"])
+	}
+	if p["dir"] != "\033[38;5;39m" {
+		t.Errorf("roles should follow base color overrides, got %q", p["dir"])
+	}
+	if p["device"] != "\033[38;5;29m" {
+		t.Errorf("invalid override should fall back to the role's default, got %q", p["device"])
+	}
+}
+
+func TestPalette_UnknownThemeFallsBack(t *testing.T) {
+	if Palette("nope", nil)["warning"] != Yellow {
+		t.Error("unknown theme should fall back to dark")
+	}
+}
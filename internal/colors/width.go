@@ -0,0 +1,122 @@
+package colors
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Width returns the number of terminal columns text occupies: escape codes
+// take no space, combining marks take none and wide glyphs (CJK, emoji) take two
+func Width(text string) int {
+	width := 0
+	for _, r := range Strip(text) {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// Truncate shortens text to at most width visible columns, ending with "…".
+// Escape codes are kept intact and a Reset is added if any were seen.
+func Truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if Width(text) <= width {
+		return text
+	}
+
+	var out strings.Builder
+	used := 0
+	styled := false
+
+	for i := 0; i < len(text); {
+		if text[i] == '\x1b' {
+			if loc := ansiPattern.FindStringIndex(text[i:]); loc != nil && loc[0] == 0 {
+				out.WriteString(text[i : i+loc[1]])
+				styled = true
+				i += loc[1]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(text[i:])
+		w := runeWidth(r)
+		if used+w > width-1 { // Keep one column for the ellipsis
+			break
+		}
+		out.WriteRune(r)
+		used += w
+		i += size
+	}
+
+	out.WriteString("…")
+	if styled {
+		out.WriteString(Reset)
+	}
+	return out.String()
+}
+
+// wideRanges are code points rendered two columns wide by common terminals
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x231A, 0x231B},   // Watch, hourglass
+	{0x23E9, 0x23EC},   // Media controls
+	{0x23F0, 0x23F0},   // Alarm clock
+	{0x23F3, 0x23F3},   // Hourglass
+	{0x25FD, 0x25FE},   // Small squares
+	{0x2614, 0x2615},   // Umbrella, hot beverage
+	{0x2648, 0x2653},   // Zodiac
+	{0x267F, 0x267F},   // Wheelchair
+	{0x2693, 0x2693},   // Anchor
+	{0x26A1, 0x26A1},   // High voltage
+	{0x26AA, 0x26AB},   // Circles
+	{0x26BD, 0x26BE},   // Sports balls
+	{0x26C4, 0x26C5},   // Snowman, sun
+	{0x26CE, 0x26CE},   // Ophiuchus
+	{0x26D4, 0x26D4},   // No entry
+	{0x26EA, 0x26EA},   // Church
+	{0x26F2, 0x26F5},   // Fountain..sailboat
+	{0x26FA, 0x26FA},   // Tent
+	{0x26FD, 0x26FD},   // Fuel pump
+	{0x2705, 0x2705},   // Check mark button
+	{0x270A, 0x270B},   // Raised fists
+	{0x2728, 0x2728},   // Sparkles
+	{0x274C, 0x274C},   // Cross mark
+	{0x274E, 0x274E},   // Cross mark button
+	{0x2753, 0x2755},   // Question marks
+	{0x2757, 0x2757},   // Exclamation mark
+	{0x2795, 0x2797},   // Plus, minus, divide
+	{0x27B0, 0x27B0},   // Curly loop
+	{0x27BF, 0x27BF},   // Double curly loop
+	{0x2B1B, 0x2B1C},   // Large squares
+	{0x2B50, 0x2B50},   // Star
+	{0x2B55, 0x2B55},   // Circle
+	{0x2E80, 0xA4CF},   // CJK radicals .. Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F300, 0x1F64F}, // Emoji: symbols, pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // Emoji: transport and map
+	{0x1F900, 0x1F9FF}, // Emoji: supplemental symbols
+	{0x1FA70, 0x1FAFF}, // Emoji: symbols and pictographs extended
+	{0x20000, 0x3FFFD}, // CJK extensions
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D, // Zero width joiner
+		r >= 0xFE00 && r <= 0xFE0F, // Variation selectors
+		r >= 0x0300 && r <= 0x036F: // Combining diacritical marks
+		return 0
+	case r < 0x1100:
+		return 1
+	}
+	for _, wr := range wideRanges {
+		if r >= wr[0] && r <= wr[1] {
+			return 2
+		}
+	}
+	return 1
+}
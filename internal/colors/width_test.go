@@ -0,0 +1,48 @@
+package colors
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"main", 4},
+		{Yellow + "main*" + Reset, 5},
+		{"💎 prism", 8},
+		{"日本", 4},
+		{"⎇ wt", 4},
+		{"é", 1},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Width(tt.input); got != tt.expected {
+			t.Errorf("Width(%q) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"feature/login", 8, "feature…"},
+		{Yellow + "feature/login" + Reset, 5, Yellow + "feat…" + Reset},
+		{"日本語", 4, "日…"},
+		{"abc", 0, ""},
+	}
+
+	for _, tt := range tests {
+		got := Truncate(tt.input, tt.width)
+		if got != tt.expected {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.expected)
+		}
+		if Width(got) > tt.width {
+			t.Errorf("Truncate(%q, %d) is %d columns wide", tt.input, tt.width, Width(got))
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache keeps merged configs in memory and re-reads the config files only
+// when one of them changes (by modification time or size). Returned configs
+// share maps with the cache and must be treated as read-only.
+type Cache struct {
+	mu      sync.Mutex
+	configs map[string]cachedConfig // Keyed by project dir
+	plugins map[string]cachedPlugin // Plugin config.json, keyed by path
+	watcher Watcher                 // Set while Watch runs
+	watched map[string]bool         // Directories added to the watcher
+}
+
+type cachedConfig struct {
+	cfg    Config
+	global string // Global config path, which follows $HOME
+	stamps []fileStamp
+}
+
+type cachedPlugin struct {
+	options map[string]any
+	stamp   fileStamp
+}
+
+// fileStamp identifies a version of a file without reading it
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.exists == other.exists && s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+func stampOf(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+// NewCache creates an empty config cache
+func NewCache() *Cache {
+	return &Cache{
+		configs: make(map[string]cachedConfig),
+		plugins: make(map[string]cachedPlugin),
+		watched: make(map[string]bool),
+	}
+}
+
+// defaultCache backs Load and LoadPluginConfig
+var defaultCache = NewCache()
+
+// Load returns the merged config for projectDir, re-reading files only if
+// one of them changed since the last call
+func (c *Cache) Load(projectDir string) Config {
+	tiers := Tiers(projectDir)
+	stamps := make([]fileStamp, len(tiers))
+	for i, tier := range tiers {
+		stamps[i] = stampOf(tier.Path)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.configs[projectDir]
+	c.mu.Unlock()
+	if ok && cached.global == tiers[0].Path && sameStamps(cached.stamps, stamps) {
+		return cached.cfg
+	}
+
+	cfg := Config{}
+	for _, tier := range tiers {
+		if tierCfg, err := loadFile(tier.Path); err == nil {
+			cfg = mergeCfg(cfg, tierCfg)
+			warnDeprecations(tier.Path)
+		}
+	}
+
+	c.mu.Lock()
+	c.configs[projectDir] = cachedConfig{cfg: cfg, global: tiers[0].Path, stamps: stamps}
+	if c.watcher != nil && projectDir != "" {
+		c.watchDir(filepath.Join(projectDir, ".claude"))
+	}
+	c.mu.Unlock()
+
+	return cfg
+}
+
+// pluginDefaults returns the options from a plugin's own config.json
+func (c *Cache) pluginDefaults(name string) map[string]any {
+	path := filepath.Join(PluginsDir(), name, "config.json")
+	stamp := stampOf(path)
+
+	c.mu.Lock()
+	cached, ok := c.plugins[path]
+	c.mu.Unlock()
+	if ok && cached.stamp.equal(stamp) {
+		return cached.options
+	}
+
+	options := make(map[string]any)
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &options)
+	}
+
+	c.mu.Lock()
+	c.plugins[path] = cachedPlugin{options: options, stamp: stamp}
+	c.mu.Unlock()
+
+	return options
+}
+
+// Invalidate drops all cached configs
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configs = make(map[string]cachedConfig)
+	c.plugins = make(map[string]cachedPlugin)
+}
+
+// Watch invalidates the cache as soon as a config file changes and then
+// calls onChange (if set), until ctx is cancelled. Project directories are
+// watched once they have been loaded. Long-lived processes use this to pick
+// up edits right away; the mtime check in Load stays in place either way.
+func (c *Cache) Watch(ctx context.Context, onChange func()) error {
+	w, err := NewWatcher(func(path string) {
+		if !isConfigFile(path) {
+			return
+		}
+		c.Invalidate()
+		if onChange != nil {
+			onChange()
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.watcher = w
+	c.watchDir(filepath.Dir(globalConfigPath()))
+	c.watchDir(PluginsDir())
+	if entries, err := os.ReadDir(PluginsDir()); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				c.watchDir(filepath.Join(PluginsDir(), entry.Name()))
+			}
+		}
+	}
+	for projectDir := range c.configs {
+		if projectDir != "" {
+			c.watchDir(filepath.Join(projectDir, ".claude"))
+		}
+	}
+	c.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		c.watcher = nil
+		c.watched = make(map[string]bool)
+		c.mu.Unlock()
+		w.Close()
+	}()
+
+	return nil
+}
+
+// watchDir adds dir to the watcher once. Callers hold c.mu.
+func (c *Cache) watchDir(dir string) {
+	if c.watched[dir] {
+		return
+	}
+	if err := c.watcher.Add(dir); err == nil {
+		c.watched[dir] = true
+	}
+}
+
+func isConfigFile(path string) bool {
+	switch filepath.Base(path) {
+	case "prism-config.json", "prism.json", "prism.local.json", "config.json":
+		return true
+	}
+	return false
+}
+
+func sameStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
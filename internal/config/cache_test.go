@@ -0,0 +1,155 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupCacheProject(t *testing.T) string {
+	t.Helper()
+	home, err := os.MkdirTemp("", "prism-test-home-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(home) })
+	t.Setenv("HOME", home)
+
+	projectDir := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	return projectDir
+}
+
+func TestCache_ReloadsOnlyWhenFilesChange(t *testing.T) {
+	projectDir := setupCacheProject(t)
+	path := filepath.Join(projectDir, ".claude", "prism.json")
+	os.WriteFile(path, []byte(`{"icon": "a"}`), 0644)
+
+	c := NewCache()
+	if got := c.Load(projectDir).Icon; got != "a" {
+		t.Fatalf("expected icon a, got %q", got)
+	}
+
+	// Same stamp: the cached config is returned even if content differs
+	info, _ := os.Stat(path)
+	os.WriteFile(path, []byte(`{"icon": "b"}`), 0644)
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	if got := c.Load(projectDir).Icon; got != "a" {
+		t.Errorf("expected cached icon a, got %q", got)
+	}
+
+	// New mtime: reloaded
+	later := info.ModTime().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if got := c.Load(projectDir).Icon; got != "b" {
+		t.Errorf("expected reloaded icon b, got %q", got)
+	}
+
+	// New local tier: reloaded
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.local.json"), []byte(`{"icon": "c"}`), 0644)
+	if got := c.Load(projectDir).Icon; got != "c" {
+		t.Errorf("expected local icon c, got %q", got)
+	}
+}
+
+func TestCache_PluginDefaultsAreCopied(t *testing.T) {
+	setupCacheProject(t)
+	pluginDir := filepath.Join(PluginsDir(), "demo")
+	os.MkdirAll(pluginDir, 0755)
+	os.WriteFile(filepath.Join(pluginDir, "config.json"), []byte(`{"threshold": 5}`), 0644)
+
+	cfg := Config{}
+	first := cfg.LoadPluginConfig("demo")
+	first["threshold"] = 99
+
+	if got := cfg.LoadPluginConfig("demo")["threshold"]; got != float64(5) {
+		t.Errorf("cached defaults were modified through a returned map: %v", got)
+	}
+}
+
+func TestWatcher_ReportsChanges(t *testing.T) {
+	dir, err := os.MkdirTemp("", "prism-test-watch-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	changed := make(chan string, 10)
+	w, err := NewWatcher(func(path string) { changed <- path })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "prism.json")
+	os.WriteFile(path, []byte(`{}`), 0644)
+
+	select {
+	case got := <-changed:
+		if got != path {
+			t.Errorf("expected change to %s, got %s", path, got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+func TestPollWatcher_Scan(t *testing.T) {
+	dir, err := os.MkdirTemp("", "prism-test-poll-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "prism.json")
+	os.WriteFile(path, []byte(`{}`), 0644)
+
+	w := newPollWatcher(func(string) {})
+	defer w.Close()
+	w.Add(dir)
+
+	if got := w.scan(); len(got) != 0 {
+		t.Errorf("expected no changes, got %v", got)
+	}
+
+	os.WriteFile(path, []byte(`{"icon": "x"}`), 0644)
+	if got := w.scan(); len(got) != 1 || got[0] != path {
+		t.Errorf("expected change to %s, got %v", path, got)
+	}
+
+	os.Remove(path)
+	if got := w.scan(); len(got) != 1 || got[0] != path {
+		t.Errorf("expected removal of %s, got %v", path, got)
+	}
+}
+
+func TestCache_WatchInvalidates(t *testing.T) {
+	projectDir := setupCacheProject(t)
+	path := filepath.Join(projectDir, ".claude", "prism.json")
+	os.WriteFile(path, []byte(`{"icon": "a"}`), 0644)
+
+	c := NewCache()
+	c.Load(projectDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notified := make(chan struct{}, 10)
+	if err := c.Watch(ctx, func() { notified <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(path, []byte(`{"icon": "b"}`), 0644)
+
+	select {
+	case <-notified:
+	case <-time.After(3 * time.Second):
+		t.Fatal("watch did not report the edit")
+	}
+	if got := c.Load(projectDir).Icon; got != "b" {
+		t.Errorf("expected icon b after edit, got %q", got)
+	}
+}
@@ -4,14 +4,90 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Config represents the Prism configuration
 type Config struct {
-	Icon              string         `json:"icon,omitempty"`
-	Sections          any            `json:"sections,omitempty"` // Can be []string or [][]string
-	Plugins           map[string]any `json:"plugins,omitempty"`
-	AutocompactBuffer *float64       `json:"autocompactBuffer,omitempty"` // Buffer percentage (default 22.5, set to 0 if disabled)
+	Icon              string             `json:"icon,omitempty"`
+	Sections          any                `json:"sections,omitempty"` // Can be []string or [][]string
+	Plugins           map[string]any     `json:"plugins,omitempty"`
+	AutocompactBuffer *float64           `json:"autocompactBuffer,omitempty"` // Buffer percentage (default 22.5, set to 0 if disabled)
+	Powerline         *Powerline         `json:"powerline,omitempty"`         // Powerline-style segment rendering
+	Theme             string             `json:"theme,omitempty"`             // Built-in color theme (dark, light, solarized, high-contrast, colorblind-safe)
+	Colors            map[string]string  `json:"colors,omitempty"`            // Color/role overrides: name, 256-color index or #rrggbb
+	ColorMode         string             `json:"colorMode,omitempty"`         // auto (default), none, 16, 256 or truecolor
+	Width             int                `json:"width,omitempty"`             // Max line width in columns (default: $COLUMNS, else unlimited)
+	DropOrder         []string           `json:"dropOrder,omitempty"`         // Sections to shorten/drop first when a line is too wide
+	Profile           string             `json:"profile,omitempty"`           // Profile to apply, overriding match rules
+	Profiles          map[string]Profile `json:"profiles,omitempty"`          // Named partial configs (see Profile)
+	LogLevel          string             `json:"log_level,omitempty"`         // Debug log: off (default), error, warn, info or debug
+	RenderBudget      int                `json:"renderBudget,omitempty"`      // Milliseconds a render may take before unfinished sections are left out
+	PluginTrust       *PluginTrust       `json:"pluginTrust,omitempty"`       // Verification of downloaded plugins
+}
+
+// PluginTrust sets how downloaded plugins are verified. Later config tiers
+// can tighten it but not loosen it, so a cloned project cannot switch off
+// verification or replace a pinned key.
+type PluginTrust struct {
+	RequireVerified bool              `json:"requireVerified,omitempty"` // Refuse plugins without a verified checksum
+	Keys            map[string]string `json:"keys,omitempty"`            // Publisher (GitHub owner or URL prefix) to PEM public key or key file
+}
+
+// RequireVerifiedPlugins reports whether unverified plugins are refused
+func (c Config) RequireVerifiedPlugins() bool {
+	return c.PluginTrust != nil && c.PluginTrust.RequireVerified
+}
+
+// mergeTrust adds overlay's requirements and keys to base without removing
+// or replacing any
+func mergeTrust(base, overlay *PluginTrust) *PluginTrust {
+	merged := &PluginTrust{Keys: make(map[string]string)}
+	if base != nil {
+		merged.RequireVerified = base.RequireVerified
+		for publisher, key := range base.Keys {
+			merged.Keys[publisher] = key
+		}
+	}
+	merged.RequireVerified = merged.RequireVerified || overlay.RequireVerified
+	for publisher, key := range overlay.Keys {
+		if _, pinned := merged.Keys[publisher]; !pinned {
+			merged.Keys[publisher] = key
+		}
+	}
+	return merged
+}
+
+// Powerline configures segment rendering with background colors and arrow separators
+type Powerline struct {
+	Enabled  bool                    `json:"enabled"`
+	Theme    string                  `json:"theme,omitempty"`    // Built-in theme (default "default")
+	ASCII    bool                    `json:"ascii,omitempty"`    // Use ">" instead of the  glyph (no Nerd Font needed)
+	Segments map[string]SegmentStyle `json:"segments,omitempty"` // Per-section color overrides
+}
+
+// SegmentStyle sets the foreground and background color names for a powerline segment
+type SegmentStyle struct {
+	FG string `json:"fg,omitempty"`
+	BG string `json:"bg,omitempty"`
+}
+
+// IsPowerline returns true if powerline rendering is enabled
+func (c Config) IsPowerline() bool {
+	return c.Powerline != nil && c.Powerline.Enabled
+}
+
+// DefaultRenderBudget leaves plugin sections their full deadline (500ms),
+// plus headroom for showing stale values
+const DefaultRenderBudget = 600 * time.Millisecond
+
+// GetRenderBudget returns how long a render may take (default DefaultRenderBudget)
+func (c Config) GetRenderBudget() time.Duration {
+	if c.RenderBudget <= 0 {
+		return DefaultRenderBudget
+	}
+	return time.Duration(c.RenderBudget) * time.Millisecond
 }
 
 // GetAutocompactBuffer returns the autocompact buffer percentage (default 22.5)
@@ -27,30 +103,86 @@ func DefaultSections() []string {
 	return []string{"dir", "model", "context", "linesChanged", "usage", "git", "android_devices"}
 }
 
-// Load reads and merges configuration from all config files
+// Load reads and merges configuration from all config files, then applies
+// the matching profile and PRISM_* environment variables and --set flags on
+// top. The merged files are cached until one of them changes, so the result
+// must be treated as read-only.
 func Load(projectDir string) Config {
-	cfg := Config{}
+	return LoadWorkspace(projectDir, projectDir)
+}
 
-	// Load global config first
-	if globalCfg, err := loadFile(globalConfigPath()); err == nil {
-		cfg = mergeCfg(cfg, globalCfg)
-	}
+// Tier is one config file, listed in merge order (later tiers win)
+type Tier struct {
+	Name string // "global", "project" or "local"; "profile", "env" or "flag" above the files
+	Path string // File path, profile name, or the variable or flag for overrides
+}
 
-	// Load project config
+// Tiers returns the config files that apply to projectDir
+func Tiers(projectDir string) []Tier {
+	tiers := []Tier{{Name: "global", Path: globalConfigPath()}}
 	if projectDir != "" {
-		projectCfgPath := filepath.Join(projectDir, ".claude", "prism.json")
-		if projectCfg, err := loadFile(projectCfgPath); err == nil {
-			cfg = mergeCfg(cfg, projectCfg)
+		tiers = append(tiers,
+			Tier{Name: "project", Path: filepath.Join(projectDir, ".claude", "prism.json")},
+			Tier{Name: "local", Path: filepath.Join(projectDir, ".claude", "prism.local.json")},
+		)
+	}
+	return tiers
+}
+
+// Explain loads the merged config for currentDir and reports which tier
+// supplied each value. Keys are top-level config keys, or "plugins.<name>",
+// "colors.<name>" and "profiles.<name>" for the maps that are merged per entry.
+func Explain(projectDir, currentDir string) (Config, map[string]Tier) {
+	sources := make(map[string]Tier)
+	for _, tier := range Tiers(projectDir) {
+		if data, err := os.ReadFile(tier.Path); err == nil {
+			addSources(sources, data, tier)
 		}
+	}
+	if name, _ := ActiveProfile(projectDir, currentDir); name != "" {
+		profile := defaultCache.Load(projectDir).Profiles[name].Config
+		data, _ := json.Marshal(profile)
+		addSources(sources, data, Tier{Name: "profile", Path: name})
+	}
+	for _, o := range Overrides() {
+		if o.Key == "" {
+			continue
+		}
+		tier := Tier{Name: "env", Path: o.Source}
+		if strings.HasPrefix(o.Source, "--set") {
+			tier.Name = "flag"
+		}
+		key, rest, _ := strings.Cut(o.Key, ".")
+		if name, _, _ := strings.Cut(rest, "."); isEntryMap(key) && name != "" {
+			key += "." + name
+		}
+		sources[key] = tier
+	}
+	return LoadWorkspace(projectDir, currentDir), sources
+}
 
-		// Load local overrides
-		localCfgPath := filepath.Join(projectDir, ".claude", "prism.local.json")
-		if localCfg, err := loadFile(localCfgPath); err == nil {
-			cfg = mergeCfg(cfg, localCfg)
+// addSources records tier as the source of every key in a config document
+func addSources(sources map[string]Tier, data []byte, tier Tier) {
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		return
+	}
+	for key, value := range raw {
+		if isEntryMap(key) {
+			var entries map[string]json.RawMessage
+			json.Unmarshal(value, &entries)
+			for name := range entries {
+				sources[key+"."+mergeKey(name)] = tier
+			}
+			continue
 		}
+		sources[key] = tier
 	}
+}
 
-	return cfg
+// isEntryMap reports whether a top-level key is merged per entry
+func isEntryMap(key string) bool {
+	return key == "plugins" || key == "colors" || key == "profiles"
 }
 
 func globalConfigPath() string {
@@ -66,24 +198,10 @@ func PluginsDir() string {
 
 // LoadPluginConfig loads a plugin's own config.json and merges with prism.json overrides
 func (c Config) LoadPluginConfig(name string) map[string]any {
-	result := make(map[string]any)
-
-	// First load plugin's own config.json
-	pluginConfigPath := filepath.Join(PluginsDir(), name, "config.json")
-	if data, err := os.ReadFile(pluginConfigPath); err == nil {
-		json.Unmarshal(data, &result)
-	}
-
-	// Then overlay with prism.json plugin config
-	if c.Plugins != nil {
-		if override, ok := c.Plugins[name].(map[string]any); ok {
-			for k, v := range override {
-				result[k] = v
-			}
-		}
-	}
-
-	return result
+	// Plugin's own config.json first, then the prism.json plugin config.
+	// deepMerge copies, so callers never share the cached defaults.
+	override, _ := c.Plugins[name].(map[string]any)
+	return deepMerge(defaultCache.pluginDefaults(name), override)
 }
 
 func loadFile(path string) (Config, error) {
@@ -104,103 +222,134 @@ func mergeCfg(base, overlay Config) Config {
 		base.Sections = overlay.Sections
 	}
 	if overlay.Plugins != nil {
-		if base.Plugins == nil {
-			base.Plugins = make(map[string]any)
-		}
-		for k, v := range overlay.Plugins {
-			base.Plugins[k] = v
-		}
+		base.Plugins = deepMerge(base.Plugins, overlay.Plugins)
 	}
 	if overlay.AutocompactBuffer != nil {
 		base.AutocompactBuffer = overlay.AutocompactBuffer
 	}
+	if overlay.Powerline != nil {
+		base.Powerline = overlay.Powerline
+	}
+	if overlay.Theme != "" {
+		base.Theme = overlay.Theme
+	}
+	if overlay.Width != 0 {
+		base.Width = overlay.Width
+	}
+	if overlay.DropOrder != nil {
+		base.DropOrder = overlay.DropOrder
+	}
+	if overlay.ColorMode != "" {
+		base.ColorMode = overlay.ColorMode
+	}
+	if overlay.Colors != nil {
+		// Copy so merging a profile never touches the cached config
+		colors := make(map[string]string, len(base.Colors)+len(overlay.Colors))
+		for k, v := range base.Colors {
+			colors[k] = v
+		}
+		for k, v := range overlay.Colors {
+			colors[k] = v
+		}
+		base.Colors = colors
+	}
+	if overlay.RenderBudget != 0 {
+		base.RenderBudget = overlay.RenderBudget
+	}
+	if overlay.PluginTrust != nil {
+		base.PluginTrust = mergeTrust(base.PluginTrust, overlay.PluginTrust)
+	}
+	if overlay.LogLevel != "" {
+		base.LogLevel = overlay.LogLevel
+	}
+	if overlay.Profile != "" {
+		base.Profile = overlay.Profile
+	}
+	if overlay.Profiles != nil {
+		profiles := make(map[string]Profile, len(base.Profiles)+len(overlay.Profiles))
+		for k, v := range base.Profiles {
+			profiles[k] = v
+		}
+		for k, v := range overlay.Profiles {
+			profiles[k] = v
+		}
+		base.Profiles = profiles
+	}
 	return base
 }
 
+// Section is one entry in "sections": either a plain name like "git" or an
+// object {"section": "usage", "when": "context_pct > 50"} that is only shown
+// while its condition holds
+type Section struct {
+	Name string
+	When string // Optional expression (see package expr)
+}
+
 // GetSections returns the configured sections as a flat list
-func (c Config) GetSections() []string {
-	if c.Sections == nil {
-		return DefaultSections()
+func (c Config) GetSections() []Section {
+	lines := c.GetAllSectionLines()
+	return lines[0]
+}
+
+// GetAllSectionLines returns sections as lines (for multi-line support).
+// Entries that are neither names nor section objects are skipped.
+func (c Config) GetAllSectionLines() [][]Section {
+	v, ok := c.Sections.([]any)
+	if !ok || len(v) == 0 {
+		return [][]Section{defaultSectionLine()}
 	}
 
-	// Handle both flat and nested section arrays
-	switch v := c.Sections.(type) {
-	case []any:
-		if len(v) == 0 {
-			return DefaultSections()
-		}
-		// Check if first element is a string (flat) or array (nested)
-		if _, ok := v[0].(string); ok {
-			// Flat array
-			sections := make([]string, len(v))
-			for i, s := range v {
-				sections[i] = s.(string)
+	// Nested if the first entry is an array
+	if _, ok := v[0].([]any); ok {
+		var lines [][]Section
+		for _, line := range v {
+			if arr, ok := line.([]any); ok {
+				lines = append(lines, parseSections(arr))
 			}
-			return sections
-		}
-		// Nested array - flatten first line for now
-		if arr, ok := v[0].([]any); ok {
-			sections := make([]string, len(arr))
-			for i, s := range arr {
-				sections[i] = s.(string)
-			}
-			return sections
 		}
+		return lines
 	}
 
-	return DefaultSections()
+	return [][]Section{parseSections(v)}
 }
 
-// IsMultiline returns true if sections are configured as multi-line
-func (c Config) IsMultiline() bool {
-	if c.Sections == nil {
-		return false
-	}
-	if arr, ok := c.Sections.([]any); ok && len(arr) > 0 {
-		_, isNested := arr[0].([]any)
-		return isNested
+func parseSections(entries []any) []Section {
+	var sections []Section
+	for _, entry := range entries {
+		if section, ok := parseSection(entry); ok {
+			sections = append(sections, section)
+		}
 	}
-	return false
+	return sections
 }
 
-// GetAllSectionLines returns sections as lines (for multi-line support)
-func (c Config) GetAllSectionLines() [][]string {
-	if c.Sections == nil {
-		return [][]string{DefaultSections()}
-	}
-
-	switch v := c.Sections.(type) {
-	case []any:
-		if len(v) == 0 {
-			return [][]string{DefaultSections()}
+func parseSection(entry any) (Section, bool) {
+	switch e := entry.(type) {
+	case string:
+		return Section{Name: e}, true
+	case map[string]any:
+		name, _ := e["section"].(string)
+		if name == "" {
+			return Section{}, false
 		}
-		// Check if nested
-		if _, ok := v[0].([]any); ok {
-			lines := make([][]string, len(v))
-			for i, line := range v {
-				if arr, ok := line.([]any); ok {
-					sections := make([]string, len(arr))
-					for j, s := range arr {
-						sections[j] = s.(string)
-					}
-					lines[i] = sections
-				}
-			}
-			return lines
-		}
-		// Flat array
-		sections := make([]string, len(v))
-		for i, s := range v {
-			sections[i] = s.(string)
-		}
-		return [][]string{sections}
+		when, _ := e["when"].(string)
+		return Section{Name: name, When: when}, true
 	}
+	return Section{}, false
+}
 
-	return [][]string{DefaultSections()}
+func defaultSectionLine() []Section {
+	names := DefaultSections()
+	sections := make([]Section, len(names))
+	for i, name := range names {
+		sections[i] = Section{Name: name}
+	}
+	return sections
 }
 
-// Init creates a new project config file
-func Init(dir string) error {
+// Init creates a new project config file with cfg
+func Init(dir string, cfg Config) error {
 	configDir := filepath.Join(dir, ".claude")
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return err
@@ -211,12 +360,7 @@ func Init(dir string) error {
 		return os.ErrExist
 	}
 
-	cfg := Config{
-		Icon:     "💎",
-		Sections: []string{"dir", "model", "context", "usage", "git"},
-	}
-
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	data, err := formatConfig(cfg)
 	if err != nil {
 		return err
 	}
@@ -241,10 +385,24 @@ func InitGlobal() error {
 		Sections: []string{"dir", "model", "context", "usage", "git"},
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	data, err := formatConfig(cfg)
 	if err != nil {
 		return err
 	}
 
 	return os.WriteFile(configPath, data, 0644)
 }
+
+// formatConfig encodes cfg the way config files are written by hand: two-space
+// indentation with short lists on one line
+func formatConfig(cfg Config) ([]byte, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeOrdered(doc), nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGetAllSectionLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected [][]Section
+	}{
+		{
+			name:     "flat",
+			json:     `{"sections": ["dir", "git"]}`,
+			expected: [][]Section{{{Name: "dir"}, {Name: "git"}}},
+		},
+		{
+			name: "nested with conditions",
+			json: `{"sections": [["dir", {"section": "usage", "when": "context_pct > 50"}], ["git"]]}`,
+			expected: [][]Section{
+				{{Name: "dir"}, {Name: "usage", When: "context_pct > 50"}},
+				{{Name: "git"}},
+			},
+		},
+		{
+			name:     "conditional first entry",
+			json:     `{"sections": [{"section": "android_devices", "when": "project has build.gradle"}, "dir"]}`,
+			expected: [][]Section{{{Name: "android_devices", When: "project has build.gradle"}, {Name: "dir"}}},
+		},
+		{
+			name:     "invalid entries are skipped",
+			json:     `{"sections": ["dir", 42, {"when": "true"}, null, "git"]}`,
+			expected: [][]Section{{{Name: "dir"}, {Name: "git"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			if err := json.Unmarshal([]byte(tt.json), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.GetAllSectionLines(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetAllSectionLines_Default(t *testing.T) {
+	lines := Config{}.GetAllSectionLines()
+	if len(lines) != 1 || len(lines[0]) != len(DefaultSections()) {
+		t.Errorf("expected default sections, got %+v", lines)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import "strings"
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// Diff returns a line diff of two files: removed lines start with "-",
+// added lines with "+", and unchanged lines near a change with a space
+func Diff(before, after []byte) string {
+	a := strings.Split(strings.TrimSuffix(string(before), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(string(after), "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	// Keep changed lines and their context; elide the rest
+	keep := make([]bool, len(lines))
+	for n, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(0, n-diffContext); k <= min(len(lines)-1, n+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out strings.Builder
+	elided := false
+	for n, l := range lines {
+		if !keep[n] {
+			if !elided {
+				out.WriteString("  ...\n")
+				elided = true
+			}
+			continue
+		}
+		elided = false
+		out.WriteByte(l.op)
+		out.WriteByte(' ')
+		out.WriteString(l.text)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
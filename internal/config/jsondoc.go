@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonObject is a JSON object that keeps its key order, so rewriting a
+// config file (see Migrate) only changes what was actually migrated
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]any)}
+}
+
+func (o *jsonObject) get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set replaces a value in place, or appends a new key
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// rename changes a key without moving it
+func (o *jsonObject) rename(from, to string) {
+	for i, key := range o.keys {
+		if key == from {
+			o.keys[i] = to
+		}
+	}
+	o.values[to] = o.values[from]
+	delete(o.values, from)
+}
+
+func (o *jsonObject) remove(key string) {
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	delete(o.values, key)
+}
+
+// decodeOrdered parses JSON into *jsonObject, []any, json.Number, string,
+// bool and nil values
+func decodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return value, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := newJSONObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				value, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.set(key, value)
+			}
+			_, err := dec.Token() // '}'
+			return obj, err
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				value, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, value)
+			}
+			_, err := dec.Token() // ']'
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	default:
+		return tok, nil
+	}
+}
+
+// encodeOrdered writes a value with two-space indentation. Arrays of plain
+// values stay on one line, like ["dir", "model", "git"].
+func encodeOrdered(value any) []byte {
+	var buf bytes.Buffer
+	writeValue(&buf, value, "")
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func writeValue(buf *bytes.Buffer, value any, indent string) {
+	switch v := value.(type) {
+	case *jsonObject:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i, key := range v.keys {
+			buf.WriteString(indent + "  ")
+			writeScalar(buf, key)
+			buf.WriteString(": ")
+			writeValue(buf, v.values[key], indent+"  ")
+			if i < len(v.keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		if isFlat(v) {
+			buf.WriteByte('[')
+			for i, item := range v {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				writeScalar(buf, item)
+			}
+			buf.WriteByte(']')
+			return
+		}
+		buf.WriteString("[\n")
+		for i, item := range v {
+			buf.WriteString(indent + "  ")
+			writeValue(buf, item, indent+"  ")
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+
+	default:
+		writeScalar(buf, v)
+	}
+}
+
+func writeScalar(buf *bytes.Buffer, value any) {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false) // Keep "<" and "&" readable in format templates
+	enc.Encode(value)
+	buf.WriteString(strings.TrimSuffix(out.String(), "\n"))
+}
+
+func isFlat(arr []any) bool {
+	for _, item := range arr {
+		switch item.(type) {
+		case *jsonObject, []any:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Key suffixes that change how a plugin option merges with lower tiers:
+//
+//	"packages+": ["com.example.debug"]   append to the inherited array
+//	"usage_plan!": {"style": "bars"}     replace the inherited value wholesale
+//
+// Without a suffix, objects merge recursively and everything else (including
+// arrays) replaces the inherited value.
+const (
+	replaceSuffix = "!"
+	appendSuffix  = "+"
+)
+
+// deepMerge returns base with overlay merged on top. Neither input is modified.
+func deepMerge(base, overlay map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(overlay))
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for _, key := range mergeOrder(overlay) {
+		value := overlay[key]
+		switch {
+		case strings.HasSuffix(key, replaceSuffix):
+			name := strings.TrimSuffix(key, replaceSuffix)
+			result[name] = mergeValue(nil, value)
+
+		case strings.HasSuffix(key, appendSuffix):
+			name := strings.TrimSuffix(key, appendSuffix)
+			inherited, _ := result[name].([]any)
+			if items, ok := value.([]any); ok {
+				combined := make([]any, 0, len(inherited)+len(items))
+				combined = append(combined, inherited...)
+				result[name] = append(combined, items...)
+			} else {
+				result[name] = mergeValue(nil, value)
+			}
+
+		default:
+			result[key] = mergeValue(result[key], value)
+		}
+	}
+
+	return result
+}
+
+// mergeOrder returns the keys of overlay with plain keys first and then the
+// suffixed keys sorted, so "x", "x!" and "x+" together merge the same way
+// every time
+func mergeOrder(overlay map[string]any) []string {
+	keys := make([]string, 0, len(overlay))
+	for key := range overlay {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		si, sj := mergeKey(keys[i]) != keys[i], mergeKey(keys[j]) != keys[j]
+		if si != sj {
+			return sj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// mergeValue merges objects recursively; other values replace the base.
+// Objects are always rebuilt so merge suffixes never leak into the result.
+func mergeValue(base, overlay any) any {
+	overlayMap, ok := overlay.(map[string]any)
+	if !ok {
+		return overlay
+	}
+	baseMap, _ := base.(map[string]any)
+	return deepMerge(baseMap, overlayMap)
+}
+
+// mergeKey returns a config key without its merge suffix
+func mergeKey(key string) string {
+	return strings.TrimRight(key, replaceSuffix+appendSuffix)
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		overlay  string
+		expected string
+	}{
+		{
+			name:     "objects merge recursively",
+			base:     `{"android_devices": {"packages": ["com.a"], "display": "serial"}}`,
+			overlay:  `{"android_devices": {"display": "model"}}`,
+			expected: `{"android_devices": {"packages": ["com.a"], "display": "model"}}`,
+		},
+		{
+			name:     "arrays replace by default",
+			base:     `{"android_devices": {"packages": ["com.a"]}}`,
+			overlay:  `{"android_devices": {"packages": ["com.b"]}}`,
+			expected: `{"android_devices": {"packages": ["com.b"]}}`,
+		},
+		{
+			name:     "plus appends",
+			base:     `{"android_devices": {"packages": ["com.a"]}}`,
+			overlay:  `{"android_devices": {"packages+": ["com.b"]}}`,
+			expected: `{"android_devices": {"packages": ["com.a", "com.b"]}}`,
+		},
+		{
+			name:     "plus without inherited array",
+			base:     `{}`,
+			overlay:  `{"android_devices": {"packages+": ["com.b"]}}`,
+			expected: `{"android_devices": {"packages": ["com.b"]}}`,
+		},
+		{
+			name:     "bang replaces objects",
+			base:     `{"usage": {"usage_plan": {"style": "bars", "show_days": false}}}`,
+			overlay:  `{"usage": {"usage_plan!": {"style": "text"}}}`,
+			expected: `{"usage": {"usage_plan": {"style": "text"}}}`,
+		},
+		{
+			name:     "bang on a whole plugin",
+			base:     `{"git": {"format": "a"}, "usage": {"x": 1}}`,
+			overlay:  `{"git!": {}}`,
+			expected: `{"git": {}, "usage": {"x": 1}}`,
+		},
+		{
+			name:     "suffixes inside new objects are cleaned up",
+			base:     `{}`,
+			overlay:  `{"usage": {"usage_plan!": {"list+": [1]}}}`,
+			expected: `{"usage": {"usage_plan": {"list": [1]}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := decode(t, tt.base)
+			baseCopy := decode(t, tt.base)
+
+			got := deepMerge(base, decode(t, tt.overlay))
+			if !reflect.DeepEqual(got, decode(t, tt.expected)) {
+				t.Errorf("got %v, want %s", got, tt.expected)
+			}
+			if !reflect.DeepEqual(base, baseCopy) {
+				t.Errorf("base was modified: %v", base)
+			}
+		})
+	}
+}
+
+func TestDeepMerge_SuffixOrder(t *testing.T) {
+	base := decode(t, `{"packages": ["com.a"]}`)
+	overlay := decode(t, `{"packages+": ["com.d"], "packages": ["com.b"], "packages!": ["com.c"]}`)
+	want := []any{"com.c", "com.d"}
+
+	// Map iteration order is random, so merge often enough to catch it
+	for i := 0; i < 100; i++ {
+		got := deepMerge(base, overlay)
+		if !reflect.DeepEqual(got["packages"], want) {
+			t.Fatalf("run %d: packages = %v, want %v", i, got["packages"], want)
+		}
+	}
+}
+
+func TestLoad_DeepMergesPluginsAcrossTiers(t *testing.T) {
+	home, err := os.MkdirTemp("", "prism-test-home-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	t.Setenv("HOME", home)
+
+	projectDir := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.json"),
+		[]byte(`{"plugins": {"android_devices": {"packages": ["com.team.app"], "display": "serial"}}}`), 0644)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.local.json"),
+		[]byte(`{"plugins": {"android_devices": {"display": "model", "packages+": ["com.me.debug"]}}}`), 0644)
+
+	// Plugin defaults from its own config.json sit below all tiers
+	pluginDir := filepath.Join(home, ".claude", "prism-plugins", "android_devices")
+	os.MkdirAll(pluginDir, 0755)
+	os.WriteFile(filepath.Join(pluginDir, "config.json"), []byte(`{"timeout": 5, "display": "version"}`), 0644)
+
+	got := Load(projectDir).LoadPluginConfig("android_devices")
+	expected := map[string]any{
+		"timeout":  float64(5),
+		"display":  "model",
+		"packages": []any{"com.team.app", "com.me.debug"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestLoad_ProjectCannotLoosenPluginTrust(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"),
+		[]byte(`{"pluginTrust": {"requireVerified": true, "keys": {"acme": "global key"}}}`), 0644)
+
+	projectDir := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.json"),
+		[]byte(`{"pluginTrust": {"requireVerified": false, "keys": {"acme": "project key", "team": "team key"}}}`), 0644)
+
+	trust := Load(projectDir).PluginTrust
+	if trust == nil || !trust.RequireVerified {
+		t.Fatalf("requireVerified was switched off by the project: %+v", trust)
+	}
+	expected := map[string]string{"acme": "global key", "team": "team key"}
+	if !reflect.DeepEqual(trust.Keys, expected) {
+		t.Errorf("keys = %v, want %v", trust.Keys, expected)
+	}
+}
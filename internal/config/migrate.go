@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// renamedSections maps deprecated section names to their replacements
+var renamedSections = map[string]string{
+	"devices": "android_devices",
+	"cost":    "usage", // usage shows the session cost for API billing
+}
+
+// renamedPlugins maps deprecated plugin option keys to their replacements
+var renamedPlugins = map[string]string{
+	"devices": "android_devices",
+}
+
+// Change is one edit made by Migrate
+type Change struct {
+	Path    string // Key path such as "sections[4]" or "plugins.devices"
+	Message string
+}
+
+func (c Change) String() string {
+	return c.Path + ": " + c.Message
+}
+
+// Migrate rewrites a config document to the current schema: deprecated
+// section names, plugin keys and options are renamed. Key order is kept, and
+// data is returned as is when nothing is deprecated.
+func Migrate(data []byte) (migrated []byte, changes []Change, err error) {
+	doc, err := decodeOrdered(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	root, ok := doc.(*jsonObject)
+	if !ok {
+		return nil, nil, fmt.Errorf("config must be a JSON object")
+	}
+
+	m := migrator{}
+	m.migrateConfig(root, "")
+	if profiles, ok := root.values["profiles"].(*jsonObject); ok {
+		for _, name := range profiles.keys {
+			if profile, ok := profiles.values[name].(*jsonObject); ok {
+				m.migrateConfig(profile, "profiles."+name)
+			}
+		}
+	}
+
+	if len(m.changes) == 0 {
+		return data, nil, nil
+	}
+	return encodeOrdered(root), m.changes, nil
+}
+
+// Deprecations lists what Migrate would change in a config document
+func Deprecations(data []byte) []Change {
+	_, changes, _ := Migrate(data)
+	return changes
+}
+
+type migrator struct {
+	changes []Change
+}
+
+func (m *migrator) add(path, format string, args ...any) {
+	m.changes = append(m.changes, Change{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// migrateConfig applies every rule to the root config or a profile
+func (m *migrator) migrateConfig(cfg *jsonObject, prefix string) {
+	if sections, ok := cfg.values["sections"].([]any); ok {
+		cfg.values["sections"] = m.migrateSections(sections, joinPath(prefix, "sections"), sectionNames(sections))
+	}
+
+	if order, ok := cfg.values["dropOrder"].([]any); ok {
+		cfg.values["dropOrder"] = m.migrateSections(order, joinPath(prefix, "dropOrder"), sectionNames(order))
+	}
+
+	if powerline, ok := cfg.values["powerline"].(*jsonObject); ok {
+		if segments, ok := powerline.values["segments"].(*jsonObject); ok {
+			m.renameKeys(segments, renamedSections, joinPath(prefix, "powerline.segments"))
+		}
+	}
+
+	if plugins, ok := cfg.values["plugins"].(*jsonObject); ok {
+		m.renameKeys(plugins, renamedPlugins, joinPath(prefix, "plugins"))
+	}
+
+	// Top-level "android" from early versions holds android_devices options
+	if android, ok := cfg.values["android"].(*jsonObject); ok {
+		if plugins, ok := cfg.values["plugins"].(*jsonObject); ok {
+			if target, ok := plugins.values["android_devices"].(*jsonObject); ok {
+				mergeMissing(target, android)
+			} else {
+				plugins.set("android_devices", android)
+			}
+			cfg.remove("android")
+		} else {
+			plugins := newJSONObject()
+			plugins.set("android_devices", android)
+			cfg.rename("android", "plugins")
+			cfg.values["plugins"] = plugins
+		}
+		m.add(joinPath(prefix, "android"), "deprecated, use plugins.android_devices")
+	}
+
+	// plugins.cost is left alone: the cost section still honours its format
+	plugins, ok := cfg.values["plugins"].(*jsonObject)
+	if !ok {
+		return
+	}
+
+	if android, ok := plugins.values["android_devices"].(*jsonObject); ok {
+		path := joinPath(prefix, "plugins.android_devices.displayMode")
+		if mode, ok := android.values["displayMode"]; ok {
+			_, hasDisplay := android.values["display"]
+			switch {
+			case mode == "model" && hasDisplay:
+				// displayMode "model" has always won over display
+				android.set("display", "model")
+				android.remove("displayMode")
+			case mode == "model":
+				android.rename("displayMode", "display")
+			default:
+				android.remove("displayMode")
+			}
+			m.add(path, "deprecated, use display")
+		}
+	}
+}
+
+// migrateSections renames entries in a flat or nested sections list. An
+// entry is dropped instead when its new name is already listed, so "cost"
+// next to "usage" doesn't show usage twice. names holds the names listed so
+// far and is updated as entries are renamed.
+func (m *migrator) migrateSections(sections []any, path string, names map[string]bool) []any {
+	kept := sections[:0]
+	for i, entry := range sections {
+		entryPath := fmt.Sprintf("%s[%d]", path, i)
+		switch e := entry.(type) {
+		case string:
+			if renamed := renamedSections[e]; renamed != "" {
+				if names[renamed] {
+					m.add(entryPath, "%q is deprecated and %q is already listed, removed", e, renamed)
+					continue
+				}
+				entry = renamed
+				names[renamed] = true
+				m.add(entryPath, "%q is deprecated, use %q", e, renamed)
+			}
+		case *jsonObject:
+			if name, ok := e.values["section"].(string); ok && renamedSections[name] != "" {
+				renamed := renamedSections[name]
+				if names[renamed] {
+					m.add(entryPath, "%q is deprecated and %q is already listed, removed", name, renamed)
+					continue
+				}
+				e.values["section"] = renamed
+				names[renamed] = true
+				m.add(entryPath, "%q is deprecated, use %q", name, renamed)
+			}
+		case []any:
+			line := m.migrateSections(e, entryPath, names)
+			if len(line) == 0 && len(e) > 0 {
+				continue // Every entry was a duplicate
+			}
+			entry = line
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// sectionNames returns the current (not deprecated) section names in a flat
+// or nested sections list
+func sectionNames(sections []any) map[string]bool {
+	names := make(map[string]bool)
+	var walk func([]any)
+	walk = func(list []any) {
+		for _, entry := range list {
+			switch e := entry.(type) {
+			case string:
+				if renamedSections[e] == "" {
+					names[e] = true
+				}
+			case *jsonObject:
+				if name, ok := e.values["section"].(string); ok && renamedSections[name] == "" {
+					names[name] = true
+				}
+			case []any:
+				walk(e)
+			}
+		}
+	}
+	walk(sections)
+	return names
+}
+
+// renameKeys renames deprecated keys of obj. When both the old and the new
+// key exist, options only under the old key are moved over.
+func (m *migrator) renameKeys(obj *jsonObject, renames map[string]string, path string) {
+	for _, key := range append([]string(nil), obj.keys...) {
+		renamed := renames[key]
+		if renamed == "" {
+			continue
+		}
+		if existing, ok := obj.values[renamed].(*jsonObject); ok {
+			if old, ok := obj.values[key].(*jsonObject); ok {
+				mergeMissing(existing, old)
+			}
+			obj.remove(key)
+		} else {
+			obj.rename(key, renamed)
+		}
+		m.add(joinPath(path, key), "deprecated, use %q", renamed)
+	}
+}
+
+// mergeMissing copies keys from src that dst does not have
+func mergeMissing(dst, src *jsonObject) {
+	for _, key := range src.keys {
+		if _, ok := dst.values[key]; !ok {
+			dst.set(key, src.values[key])
+		}
+	}
+}
+
+var (
+	warnMu     sync.Mutex
+	warnOutput io.Writer
+)
+
+// SetWarningOutput makes Load report deprecated keys in the config files it
+// reads to w (nil disables this, the default)
+func SetWarningOutput(w io.Writer) {
+	warnMu.Lock()
+	defer warnMu.Unlock()
+	warnOutput = w
+}
+
+// warnDeprecations reports deprecated keys in a config file, if enabled
+func warnDeprecations(path string) {
+	warnMu.Lock()
+	w := warnOutput
+	warnMu.Unlock()
+	if w == nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for _, change := range Deprecations(data) {
+		fmt.Fprintf(w, "prism: %s: %s (run 'prism config migrate')\n", path, change)
+	}
+}
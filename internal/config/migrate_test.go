@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	data := `{
+  "icon": "🚀",
+  "sections": [["dir", "cost"], [{"section": "devices", "when": "cwd has build.gradle"}]],
+  "dropOrder": ["devices"],
+  "android": {"packages": ["com.a"]},
+  "plugins": {
+    "git": {"format": "{{if .dirty}}<*>{{end}}"},
+    "devices": {"displayMode": "model"},
+    "cost": {"format": "{{.cost}}"}
+  },
+  "powerline": {"enabled": true, "segments": {"cost": {"bg": "gold"}}},
+  "profiles": {"mobile": {"sections": ["devices"]}}
+}
+`
+
+	migrated, changes, err := Migrate([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "icon": "🚀",
+  "sections": [
+    ["dir", "usage"],
+    [
+      {
+        "section": "android_devices",
+        "when": "cwd has build.gradle"
+      }
+    ]
+  ],
+  "dropOrder": ["android_devices"],
+  "plugins": {
+    "git": {
+      "format": "{{if .dirty}}<*>{{end}}"
+    },
+    "android_devices": {
+      "display": "model",
+      "packages": ["com.a"]
+    },
+    "cost": {
+      "format": "{{.cost}}"
+    }
+  },
+  "powerline": {
+    "enabled": true,
+    "segments": {
+      "usage": {
+        "bg": "gold"
+      }
+    }
+  },
+  "profiles": {
+    "mobile": {
+      "sections": ["android_devices"]
+    }
+  }
+}
+`
+	if string(migrated) != want {
+		t.Errorf("unexpected result:\n%s", migrated)
+	}
+
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.Path
+	}
+	wantPaths := "sections[0][1] sections[1][0] dropOrder[0] powerline.segments.cost plugins.devices android plugins.android_devices.displayMode profiles.mobile.sections[0]"
+	if got := strings.Join(paths, " "); got != wantPaths {
+		t.Errorf("unexpected changes:\n got %s\nwant %s", got, wantPaths)
+	}
+
+	// The result is valid and migrating again is a no-op
+	if problems := Validate(migrated); len(problems) != 0 {
+		t.Errorf("migrated config has problems: %v", problems)
+	}
+	again, changes, err := Migrate(migrated)
+	if err != nil || len(changes) != 0 || !bytes.Equal(again, migrated) {
+		t.Errorf("expected no further changes, got %v (%v)", changes, err)
+	}
+}
+
+func TestMigrate_AndroidWithoutPlugins(t *testing.T) {
+	migrated, _, err := Migrate([]byte(`{"android": {"packages": ["com.a"]}, "theme": "light"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"plugins\": {\n    \"android_devices\": {\n      \"packages\": [\"com.a\"]\n    }\n  },\n  \"theme\": \"light\"\n}\n"
+	if string(migrated) != want {
+		t.Errorf("expected plugins in place of android, got:\n%s", migrated)
+	}
+}
+
+func TestMigrate_DisplayMode(t *testing.T) {
+	tests := []struct {
+		android string
+		want    string
+	}{
+		{`{"displayMode": "model"}`, `{"display":"model"}`},
+		{`{"display": "serial", "displayMode": "model"}`, `{"display":"model"}`},
+		{`{"display": "serial", "displayMode": "serial"}`, `{"display":"serial"}`},
+		{`{"displayMode": "serial"}`, `{}`},
+	}
+	for _, tt := range tests {
+		migrated, _, err := Migrate([]byte(`{"plugins": {"android_devices": ` + tt.android + `}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Join(strings.Fields(string(migrated)), "")
+		if want := `"android_devices":` + tt.want; !strings.Contains(got, want) {
+			t.Errorf("%s migrated to %s, want %s", tt.android, got, want)
+		}
+	}
+}
+
+func TestMigrate_DropsDuplicates(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"sections": ["dir", "cost", "usage"]}`, `["dir","usage"]`},
+		{`{"sections": ["usage", "cost"]}`, `["usage"]`},
+		{`{"sections": ["cost", "devices", "cost"]}`, `["usage","android_devices"]`},
+		{`{"sections": [["dir", "usage"], [{"section": "cost"}]]}`, `[["dir","usage"]]`},
+		{`{"dropOrder": ["cost", "usage"]}`, `["usage"]`},
+	}
+	for _, tt := range tests {
+		migrated, _, err := Migrate([]byte(tt.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Join(strings.Fields(string(migrated)), "")
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s migrated to %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	for _, data := range []string{`{"sections": [}`, `["dir"]`, `{} {}`} {
+		if _, _, err := Migrate([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\n"
+	after := "a\nb\nc\nD\ne\nf\ng\nh\n"
+
+	want := "  ...\n  b\n  c\n- d\n+ D\n  e\n  f\n  g\n+ h\n"
+	if got := Diff([]byte(before), []byte(after)); got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// envPrefix marks environment variables that override config keys, e.g.
+// PRISM_THEME=light or PRISM_PLUGINS_ANDROID_DEVICES_DISPLAY=model:version
+const envPrefix = "PRISM_"
+
+// Override sets one config key on top of all config files. Overrides come
+// from PRISM_* environment variables and from --set key=value flags.
+type Override struct {
+	Key    string // Dotted key path such as "plugins.android_devices.display"
+	Value  string // Raw value; typed by the schema when applied
+	Source string // "PRISM_THEME" or "--set theme=light"
+}
+
+var (
+	flagMu        sync.Mutex
+	flagOverrides []Override
+)
+
+// SetFlagOverrides records --set key=value flags for this process. They are
+// applied after the environment, so flags win.
+func SetFlagOverrides(overrides []Override) {
+	flagMu.Lock()
+	defer flagMu.Unlock()
+	flagOverrides = overrides
+}
+
+// ParseSetFlag parses the argument of a --set flag
+func ParseSetFlag(arg string) (Override, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return Override{}, fmt.Errorf("invalid --set %q: expected key=value", arg)
+	}
+	return Override{Key: key, Value: value, Source: "--set " + arg}, nil
+}
+
+// Overrides returns the active overrides in the order they apply: PRISM_*
+// environment variables sorted by name, then --set flags. Environment
+// variables whose name does not resolve to a config key are returned with
+// an empty Key so callers can report them.
+func Overrides() []Override {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envPrefix) {
+			env = append(env, kv)
+		}
+	}
+	sort.Strings(env)
+
+	var overrides []Override
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		overrides = append(overrides, Override{
+			Key:    resolveEnvKey(strings.TrimPrefix(name, envPrefix)),
+			Value:  value,
+			Source: name,
+		})
+	}
+
+	flagMu.Lock()
+	overrides = append(overrides, flagOverrides...)
+	flagMu.Unlock()
+
+	return overrides
+}
+
+// applyOverrides returns cfg with the overrides set. cfg itself is not
+// modified. Overrides that cannot be parsed are skipped, like config files
+// that cannot be parsed; ValidateOverrides reports them.
+func applyOverrides(cfg Config, overrides []Override) Config {
+	if len(overrides) == 0 {
+		return cfg
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return cfg
+	}
+	doc := make(map[string]any)
+	json.Unmarshal(data, &doc)
+
+	root := schema()
+	applied := 0
+	for _, o := range overrides {
+		if o.Key == "" {
+			continue
+		}
+		path := strings.Split(o.Key, ".")
+		value, err := parseOverrideValue(o.Key, schemaAt(root, path), o.Value)
+		if err != nil {
+			continue
+		}
+		setPath(doc, path, value)
+		applied++
+	}
+	if applied == 0 {
+		return cfg
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return cfg
+	}
+	var result Config
+	if json.Unmarshal(data, &result) != nil {
+		return cfg
+	}
+	return result
+}
+
+// ValidateOverrides checks the active overrides: environment variables that
+// match no config key, values that do not parse, and values the schema rejects
+func ValidateOverrides() []Problem {
+	root := schema()
+
+	var problems []Problem
+	for _, o := range Overrides() {
+		if o.Key == "" {
+			problems = append(problems, Problem{File: o.Source, Message: "does not match a config key"})
+			continue
+		}
+		path := strings.Split(o.Key, ".")
+		value, err := parseOverrideValue(o.Key, schemaAt(root, path), o.Value)
+		if err != nil {
+			problems = append(problems, Problem{File: o.Source, Path: o.Key, Message: err.Error()})
+			continue
+		}
+
+		doc := make(map[string]any)
+		setPath(doc, path, value)
+		v := validator{root: root}
+		v.check(root, doc, "")
+		for _, p := range v.problems {
+			p.File = o.Source
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// resolveEnvKey maps the part of an environment variable name after PRISM_
+// to a dotted key path, using the schema and the installed plugins to tell
+// word separators from key separators: ANDROID_DEVICES_DISPLAY becomes
+// "android_devices.display". Names are case-insensitive and underscores
+// inside a key are optional, so COLOR_MODE and COLORMODE both match
+// "colorMode". Returns "" when the name matches no key.
+func resolveEnvKey(name string) string {
+	if name == "" {
+		return ""
+	}
+	root := schema()
+	parts := strings.Split(strings.ToLower(name), "_")
+
+	v := validator{root: root}
+	var path []string
+	node := root
+	for len(parts) > 0 {
+		node = v.resolve(node)
+
+		candidates := schemaKeys(node)
+		if len(path) == 1 && path[0] == "plugins" {
+			candidates = append(candidates, installedPlugins()...)
+		}
+
+		matched := false
+		for n := len(parts); n > 0 && !matched; n-- {
+			want := strings.Join(parts[:n], "")
+			for _, key := range candidates {
+				if normalizeKey(key) == want {
+					path = append(path, key)
+					node = childSchema(node, key)
+					parts = parts[n:]
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+
+		extra, open := additionalSchema(node)
+		if !open {
+			return ""
+		}
+		if isObjectSchema(extra) {
+			// A map of objects, e.g. an unknown plugin: one word names the entry
+			path = append(path, parts[0])
+			node = extra
+			parts = parts[1:]
+			continue
+		}
+		// A leaf map such as a plugin's options: the rest is the key
+		path = append(path, strings.Join(parts, "_"))
+		parts = nil
+	}
+
+	return strings.Join(path, ".")
+}
+
+// parseOverrideValue converts a raw override value to the type the schema
+// expects at key. Arrays accept comma-separated values; "sections" also
+// accepts ";" between lines. Keys without a schema take JSON when the value
+// parses as JSON, and the raw string otherwise.
+func parseOverrideValue(key string, schema map[string]any, raw string) (any, error) {
+	trimmed := strings.TrimSpace(raw)
+
+	switch schemaType(schema) {
+	case "array":
+		if strings.HasPrefix(trimmed, "[") {
+			return decodeOverrideJSON(trimmed, "array")
+		}
+		if key == "sections" && strings.Contains(trimmed, ";") {
+			var lines []any
+			for _, line := range strings.Split(trimmed, ";") {
+				lines = append(lines, splitList(line))
+			}
+			return lines, nil
+		}
+		return splitList(trimmed), nil
+	case "number", "integer":
+		n, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", raw)
+		}
+		return b, nil
+	case "object":
+		return decodeOverrideJSON(trimmed, "object")
+	case "string":
+		return raw, nil
+	}
+
+	var value any
+	if json.Unmarshal([]byte(trimmed), &value) == nil {
+		return value, nil
+	}
+	return raw, nil
+}
+
+func decodeOverrideJSON(raw, want string) (any, error) {
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("expected a JSON %s: %v", want, err)
+	}
+	return value, nil
+}
+
+func splitList(s string) []any {
+	items := []any{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setPath sets doc[path[0]][path[1]]... = value, creating objects as needed
+func setPath(doc map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			doc[key] = next
+		}
+		doc = next
+	}
+	doc[path[len(path)-1]] = value
+}
+
+// schemaAt returns the resolved schema for a dotted key path, or nil when
+// the schema does not describe it
+func schemaAt(root map[string]any, path []string) map[string]any {
+	v := validator{root: root}
+	node := root
+	for _, key := range path {
+		if node = childSchema(v.resolve(node), key); node == nil {
+			return nil
+		}
+	}
+	return v.resolve(node)
+}
+
+// childSchema returns the schema of node's key, from its properties or else
+// from additionalProperties
+func childSchema(node map[string]any, key string) map[string]any {
+	props, _ := node["properties"].(map[string]any)
+	if prop, ok := props[key].(map[string]any); ok {
+		return prop
+	}
+	extra, _ := additionalSchema(node)
+	return extra
+}
+
+// additionalSchema reports whether node accepts keys beyond its properties,
+// and the schema of those values when it has one
+func additionalSchema(node map[string]any) (map[string]any, bool) {
+	switch extra := node["additionalProperties"].(type) {
+	case bool:
+		return nil, extra
+	case map[string]any:
+		return extra, true
+	}
+	// JSON Schema allows extra keys by default, but only objects have keys
+	return nil, schemaType(node) == "object" || node["properties"] != nil
+}
+
+func isObjectSchema(node map[string]any) bool {
+	return node != nil && schemaType(node) == "object"
+}
+
+// schemaType returns a node's type, inferring "string" for string enums
+func schemaType(node map[string]any) string {
+	if node == nil {
+		return ""
+	}
+	if t, ok := node["type"].(string); ok {
+		return t
+	}
+	if enum, ok := node["enum"].([]any); ok && len(enum) > 0 {
+		if _, ok := enum[0].(string); ok {
+			return "string"
+		}
+	}
+	return ""
+}
+
+func schemaKeys(node map[string]any) []string {
+	props, _ := node["properties"].(map[string]any)
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		if key != "$schema" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// installedPlugins lists plugin directories so their names resolve as one key
+func installedPlugins() []string {
+	entries, err := os.ReadDir(PluginsDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
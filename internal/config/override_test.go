@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	os.MkdirAll(filepath.Join(PluginsDir(), "build_status"), 0755)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"SECTIONS", "sections"},
+		{"THEME", "theme"},
+		{"COLOR_MODE", "colorMode"},
+		{"COLORMODE", "colorMode"},
+		{"AUTOCOMPACT_BUFFER", "autocompactBuffer"},
+		{"POWERLINE_ENABLED", "powerline.enabled"},
+		{"POWERLINE_SEGMENTS_GIT_BG", "powerline.segments.git.bg"},
+		{"PLUGINS_ANDROID_DEVICES_DISPLAY", "plugins.android_devices.display"},
+		{"PLUGINS_USAGE_BARS_SHOW_HOURS", "plugins.usage_bars.show_hours"},
+		{"PLUGINS_USAGE_USAGE_PLAN_STYLE", "plugins.usage.usage_plan.style"},
+		{"PLUGINS_LINES_CHANGED_FORMAT", "plugins.linesChanged.format"},
+		{"PLUGINS_BUILD_STATUS_BRANCH_NAME", "plugins.build_status.branch_name"},
+		{"PLUGINS_WEATHER_UNITS", "plugins.weather.units"},
+		{"COLORS_GIT_DIRTY", "colors.git_dirty"},
+		{"NOPE", ""},
+		{"THEME_EXTRA", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveEnvKey(tt.name); got != tt.want {
+				t.Errorf("resolveEnvKey(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_ParsedOnce(t *testing.T) {
+	first, second := schema(), schema()
+	if first == nil || reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+		t.Error("schema() should decode the embedded schema once and share it")
+	}
+}
+
+func TestParseOverrideValue(t *testing.T) {
+	root := schema()
+
+	tests := []struct {
+		key  string
+		raw  string
+		want any
+	}{
+		{"sections", "dir,model, git", []any{"dir", "model", "git"}},
+		{"sections", "dir,model;git", []any{[]any{"dir", "model"}, []any{"git"}}},
+		{"sections", `["dir",{"section":"git","when":"true"}]`, []any{"dir", map[string]any{"section": "git", "when": "true"}}},
+		{"width", "80", float64(80)},
+		{"powerline.enabled", "true", true},
+		{"theme", "light", "light"},
+		{"plugins.android_devices.display", "model:version", "model:version"},
+		{"plugins.weather.units", "metric", "metric"},
+		{"plugins.weather.limit", "3", float64(3)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseOverrideValue(tt.key, schemaAt(root, strings.Split(tt.key, ".")), tt.raw)
+		if err != nil {
+			t.Errorf("%s=%s: %v", tt.key, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s=%s: got %#v, want %#v", tt.key, tt.raw, got, tt.want)
+		}
+	}
+
+	if _, err := parseOverrideValue("width", schemaAt(root, []string{"width"}), "wide"); err == nil {
+		t.Error("expected an error for a non-numeric width")
+	}
+}
+
+func TestLoad_AppliesOverridesOnTop(t *testing.T) {
+	projectDir := setupCacheProject(t)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.json"), []byte(`{
+		"theme": "dark",
+		"powerline": {"enabled": true, "theme": "mono"},
+		"plugins": {"android_devices": {"display": "serial", "packages": ["com.a"]}}
+	}`), 0644)
+
+	t.Setenv("PRISM_THEME", "light")
+	t.Setenv("PRISM_SECTIONS", "dir,model,git")
+	t.Setenv("PRISM_PLUGINS_ANDROID_DEVICES_DISPLAY", "model:version")
+	t.Setenv("PRISM_POWERLINE_THEME", "pastel")
+	SetFlagOverrides([]Override{{Key: "theme", Value: "solarized", Source: "--set theme=solarized"}})
+	t.Cleanup(func() { SetFlagOverrides(nil) })
+
+	cfg := Load(projectDir)
+
+	if cfg.Theme != "solarized" {
+		t.Errorf("expected the flag to win over the environment, got theme %q", cfg.Theme)
+	}
+	if got := cfg.GetSections(); len(got) != 3 || got[2].Name != "git" {
+		t.Errorf("unexpected sections %+v", got)
+	}
+	if !cfg.IsPowerline() || cfg.Powerline.Theme != "pastel" {
+		t.Errorf("expected powerline to stay enabled with theme pastel, got %+v", cfg.Powerline)
+	}
+	opts := cfg.LoadPluginConfig("android_devices")
+	if opts["display"] != "model:version" {
+		t.Errorf("expected display override, got %v", opts["display"])
+	}
+	if packages, _ := opts["packages"].([]any); len(packages) != 1 {
+		t.Errorf("expected other plugin options to be kept, got %v", opts["packages"])
+	}
+
+	// The cached file config is not modified by overrides
+	if got := defaultCache.Load(projectDir).Theme; got != "dark" {
+		t.Errorf("expected cached theme dark, got %q", got)
+	}
+}
+
+func TestValidateOverrides(t *testing.T) {
+	setupCacheProject(t)
+	t.Setenv("PRISM_THEME", "neon")
+	t.Setenv("PRISM_WIDTH", "wide")
+	t.Setenv("PRISM_NOPE", "1")
+
+	problems := ValidateOverrides()
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems, got %v", problems)
+	}
+	for _, p := range problems {
+		if p.File == "" {
+			t.Errorf("expected the variable name in %v", p)
+		}
+	}
+}
+
+func TestParseSetFlag(t *testing.T) {
+	o, err := ParseSetFlag("plugins.git.format=x=y")
+	if err != nil || o.Key != "plugins.git.format" || o.Value != "x=y" {
+		t.Errorf("unexpected override %+v (%v)", o, err)
+	}
+	if _, err := ParseSetFlag("theme"); err == nil {
+		t.Error("expected an error without =")
+	}
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Profile is a named partial config applied on top of the config files.
+// A profile is used when it is selected by name (the "profile" key, which
+// PRISM_PROFILE and --set profile=... override) or, failing that, when its
+// match rules hold.
+type Profile struct {
+	Config
+	Match *ProfileMatch `json:"match,omitempty"`
+}
+
+// ProfileMatch selects a profile automatically. Every rule that is set must
+// hold; a profile without rules is only used when selected by name.
+type ProfileMatch struct {
+	Path   string `json:"path,omitempty"`   // Glob on the current dir relative to the project dir, e.g. "app/**"; absolute or ~/ patterns match the full path
+	Branch string `json:"branch,omitempty"` // Glob on the current git branch, e.g. "release/*"
+	File   string `json:"file,omitempty"`   // Marker file in the current dir or a parent up to the project dir, e.g. "build.gradle.kts"
+}
+
+// LoadWorkspace is Load for a specific working directory: after merging the
+// config files it applies the profile selected for currentDir, then the
+// PRISM_* and --set overrides.
+func LoadWorkspace(projectDir, currentDir string) Config {
+	return defaultCache.LoadWorkspace(projectDir, currentDir, Overrides())
+}
+
+// LoadWorkspace returns the cached config for projectDir with the profile
+// for currentDir and then the given overrides applied. The daemon passes the
+// overrides of the client it renders for, not its own.
+func (c *Cache) LoadWorkspace(projectDir, currentDir string, overrides []Override) Config {
+	cfg := c.Load(projectDir)
+	if name, _ := selectProfile(cfg, projectDir, currentDir, overrides); name != "" {
+		cfg = mergeCfg(cfg, cfg.Profiles[name].Config)
+	}
+	return applyOverrides(cfg, overrides)
+}
+
+// ActiveProfile returns the profile that applies to currentDir and why, or
+// "" when none does
+func ActiveProfile(projectDir, currentDir string) (name, reason string) {
+	return selectProfile(defaultCache.Load(projectDir), projectDir, currentDir, Overrides())
+}
+
+// selectProfile picks the profile named by the "profile" key (overrides
+// first), or else the first profile by name whose match rules hold
+func selectProfile(cfg Config, projectDir, currentDir string, overrides []Override) (string, string) {
+	if len(cfg.Profiles) == 0 {
+		return "", ""
+	}
+
+	explicit, source := cfg.Profile, "config"
+	for _, o := range overrides {
+		if o.Key == "profile" {
+			explicit, source = strings.TrimSpace(o.Value), o.Source
+		}
+	}
+	if explicit != "" {
+		if _, ok := cfg.Profiles[explicit]; ok {
+			return explicit, "selected by " + source
+		}
+		return "", ""
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ws := workspaceInfo{projectDir: projectDir, currentDir: currentDir}
+	for _, name := range names {
+		if m := cfg.Profiles[name].Match; m != nil && m.matches(&ws) {
+			return name, "matched " + m.String()
+		}
+	}
+	return "", ""
+}
+
+// workspaceInfo looks up the git branch lazily, since most rules don't need it
+type workspaceInfo struct {
+	projectDir, currentDir string
+	branch                 *string
+}
+
+func (w *workspaceInfo) gitBranch() string {
+	if w.branch == nil {
+		branch := readGitBranch(w.dir())
+		w.branch = &branch
+	}
+	return *w.branch
+}
+
+func (w *workspaceInfo) dir() string {
+	if w.currentDir != "" {
+		return w.currentDir
+	}
+	return w.projectDir
+}
+
+func (m ProfileMatch) matches(ws *workspaceInfo) bool {
+	if m.Path == "" && m.Branch == "" && m.File == "" {
+		return false
+	}
+	if m.Path != "" && !matchPath(m.Path, ws.projectDir, ws.dir()) {
+		return false
+	}
+	if m.Branch != "" && !globMatch(m.Branch, ws.gitBranch()) {
+		return false
+	}
+	if m.File != "" && !hasMarker(m.File, ws.projectDir, ws.dir()) {
+		return false
+	}
+	return true
+}
+
+func (m ProfileMatch) String() string {
+	var rules []string
+	if m.Path != "" {
+		rules = append(rules, "path "+m.Path)
+	}
+	if m.Branch != "" {
+		rules = append(rules, "branch "+m.Branch)
+	}
+	if m.File != "" {
+		rules = append(rules, "file "+m.File)
+	}
+	return strings.Join(rules, ", ")
+}
+
+// matchPath matches pattern against dir relative to projectDir ("." for the
+// project dir itself), or against the absolute dir for absolute patterns
+func matchPath(pattern, projectDir, dir string) bool {
+	if strings.HasPrefix(pattern, "~/") {
+		home, _ := os.UserHomeDir()
+		pattern = filepath.Join(home, pattern[2:])
+	}
+	if filepath.IsAbs(pattern) {
+		return globMatch(filepath.ToSlash(pattern), filepath.ToSlash(filepath.Clean(dir)))
+	}
+	if projectDir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(projectDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return globMatch(pattern, filepath.ToSlash(rel))
+}
+
+// hasMarker reports whether name (which may be a glob) exists in dir or one
+// of its parents, stopping at projectDir
+func hasMarker(name, projectDir, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	for {
+		matches, err := filepath.Glob(filepath.Join(dir, name))
+		if err == nil && len(matches) > 0 {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if dir == projectDir || projectDir == "" || parent == dir || !strings.HasPrefix(dir, projectDir) {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// readGitBranch reads the checked-out branch from .git/HEAD without running
+// git. It returns "" outside a repository and on a detached HEAD.
+func readGitBranch(dir string) string {
+	for dir != "" {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			if !info.IsDir() {
+				// Worktrees and submodules: ".git" is a file pointing at the git dir
+				data, err := os.ReadFile(gitPath)
+				if err != nil {
+					return ""
+				}
+				gitDir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+				gitPath = gitDir
+			}
+			head, err := os.ReadFile(filepath.Join(gitPath, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			ref := strings.TrimSpace(string(head))
+			if !strings.HasPrefix(ref, "ref: refs/heads/") {
+				return ""
+			}
+			return strings.TrimPrefix(ref, "ref: refs/heads/")
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// globMatch matches "/"-separated names where "*" and "?" stay within one
+// segment and "**" spans segments. "dir/**" also matches "dir" itself.
+func globMatch(pattern, name string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					re.WriteString("(.*/)?") // "**/" matches zero or more directories
+				} else if strings.HasSuffix(re.String(), "/") {
+					// "dir/**": drop the slash so "dir" matches too
+					s := strings.TrimSuffix(re.String(), "/")
+					re.Reset()
+					re.WriteString(s + "(/.*)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	matched, err := regexp.MatchString(re.String(), name)
+	return err == nil && matched
+}
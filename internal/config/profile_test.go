@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"app/**", "app", true},
+		{"app/**", "app/src/main", true},
+		{"app/**", "apps", false},
+		{"app/*", "app/src", true},
+		{"app/*", "app/src/main", false},
+		{"**/android", "mobile/android", true},
+		{"**/android", "android", true},
+		{"release/*", "release/1.2", true},
+		{"release/*", "main", false},
+		{"feature/**", "feature/a/b", true},
+		{"v?.?", "v1.2", true},
+		{"a.b", "axb", false},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func setupProfileProject(t *testing.T) string {
+	t.Helper()
+	projectDir := setupCacheProject(t)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.json"), []byte(`{
+		"sections": ["dir", "git"],
+		"colors": {"warning": "gold"},
+		"profiles": {
+			"mobile": {
+				"match": {"path": "app/**"},
+				"sections": ["dir", "git", "android_devices"],
+				"colors": {"error": "crimson"}
+			},
+			"backend": {"match": {"path": "server/**"}, "sections": ["dir", "model"]},
+			"release": {"match": {"branch": "release/*"}, "icon": "🚀"},
+			"gradle": {"match": {"file": "build.gradle*"}, "theme": "light"},
+			"pairing": {"icon": "👥"}
+		}
+	}`), 0644)
+	for _, dir := range []string{"app/src", "server", ".git"} {
+		os.MkdirAll(filepath.Join(projectDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(projectDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	return projectDir
+}
+
+func TestLoadWorkspace_MatchesPath(t *testing.T) {
+	projectDir := setupProfileProject(t)
+
+	app := LoadWorkspace(projectDir, filepath.Join(projectDir, "app", "src"))
+	if got := app.GetSections(); len(got) != 3 || got[2].Name != "android_devices" {
+		t.Errorf("expected mobile sections in app/, got %+v", got)
+	}
+	if app.Colors["warning"] != "gold" || app.Colors["error"] != "crimson" {
+		t.Errorf("expected colors merged per entry, got %v", app.Colors)
+	}
+
+	server := LoadWorkspace(projectDir, filepath.Join(projectDir, "server"))
+	if got := server.GetSections(); len(got) != 2 || got[1].Name != "model" {
+		t.Errorf("expected backend sections in server/, got %+v", got)
+	}
+
+	root := LoadWorkspace(projectDir, projectDir)
+	if got := root.GetSections(); len(got) != 2 || got[1].Name != "git" {
+		t.Errorf("expected no profile at the root, got %+v", got)
+	}
+
+	// Profiles never modify the cached file config
+	if cached := defaultCache.Load(projectDir); len(cached.Colors) != 1 {
+		t.Errorf("cached colors were modified: %v", cached.Colors)
+	}
+}
+
+func TestLoadWorkspace_MatchesBranchAndFile(t *testing.T) {
+	projectDir := setupProfileProject(t)
+
+	os.WriteFile(filepath.Join(projectDir, ".git", "HEAD"), []byte("ref: refs/heads/release/2.0\n"), 0644)
+	if got := LoadWorkspace(projectDir, projectDir).Icon; got != "🚀" {
+		t.Errorf("expected release icon, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(projectDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "app", "build.gradle.kts"), nil, 0644)
+	cfg := LoadWorkspace(projectDir, filepath.Join(projectDir, "app", "src"))
+	if cfg.Theme != "light" {
+		t.Errorf("expected the marker in a parent dir to match, got theme %q", cfg.Theme)
+	}
+	if got := cfg.GetSections(); len(got) != 2 {
+		t.Errorf("expected only the first matching profile by name (gradle), got %+v", got)
+	}
+}
+
+func TestLoadWorkspace_ExplicitProfile(t *testing.T) {
+	projectDir := setupProfileProject(t)
+	appDir := filepath.Join(projectDir, "app")
+
+	t.Setenv("PRISM_PROFILE", "pairing")
+	cfg := LoadWorkspace(projectDir, appDir)
+	if cfg.Icon != "👥" {
+		t.Errorf("expected the pairing profile, got icon %q", cfg.Icon)
+	}
+	if got := cfg.GetSections(); len(got) != 2 {
+		t.Errorf("expected match rules to be ignored when a profile is selected, got %+v", got)
+	}
+
+	name, reason := ActiveProfile(projectDir, appDir)
+	if name != "pairing" || reason != "selected by PRISM_PROFILE" {
+		t.Errorf("unexpected active profile %q (%s)", name, reason)
+	}
+}
+
+func TestReadGitBranch_Worktree(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, "main.git", "worktrees", "wt")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/x\n"), 0644)
+
+	wt := filepath.Join(dir, "wt")
+	os.MkdirAll(filepath.Join(wt, "sub"), 0755)
+	os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644)
+
+	if got := readGitBranch(filepath.Join(wt, "sub")); got != "feature/x" {
+		t.Errorf("expected feature/x, got %q", got)
+	}
+}
+
+func TestValidate_Profiles(t *testing.T) {
+	data := `{
+		"profiles": {
+			"mobile": {"match": {"path": "app/**", "dir": "x"}, "theme": "neon"},
+			"ok": {"sections": ["dir"], "plugins": {"git": {"format": "{{.branch}}"}}}
+		}
+	}`
+
+	problems := Validate([]byte(data))
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if problems[0].Path != "profiles.mobile.match.dir" || problems[1].Path != "profiles.mobile.theme" {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
@@ -0,0 +1,455 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/himattm/prism/internal/expr"
+)
+
+// schemaJSON is the JSON Schema for prism.json, including the options of the
+// built-in plugins. Editors can use it via "$schema" for completion.
+//
+//go:embed schema.json
+var schemaJSON []byte
+
+// Schema returns the config JSON Schema
+func Schema() []byte {
+	return schemaJSON
+}
+
+// Problem is one validation error in a config file
+type Problem struct {
+	File    string // Config file path (empty when validating raw data)
+	Path    string // Key path such as "plugins.git.format" or "sections[2]"
+	Message string
+}
+
+func (p Problem) String() string {
+	var parts []string
+	if p.File != "" {
+		parts = append(parts, p.File)
+	}
+	if p.Path != "" {
+		parts = append(parts, p.Path)
+	}
+	parts = append(parts, p.Message)
+	return strings.Join(parts, ": ")
+}
+
+// Validate checks a config document against the schema and checks that
+// "when" conditions parse
+func Validate(data []byte) []Problem {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []Problem{{Message: describeJSONError(data, err)}}
+	}
+
+	root := schema()
+	v := validator{root: root}
+	v.check(root, doc, "")
+
+	if obj, ok := doc.(map[string]any); ok {
+		v.checkConditions(obj, "")
+
+		// A profile holds any config keys besides "match"; check those
+		// against the root schema
+		profiles, _ := obj["profiles"].(map[string]any)
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			profile, ok := profiles[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			path := "profiles." + name
+			body := make(map[string]any, len(profile))
+			for key, value := range profile {
+				if key == "profiles" || key == "profile" {
+					v.add(joinPath(path, key), "not allowed inside a profile")
+					continue
+				}
+				if key != "match" {
+					body[key] = value
+				}
+			}
+			sub := validator{root: root}
+			sub.check(root, body, path)
+			sub.checkConditions(body, path)
+			v.problems = append(v.problems, sub.problems...)
+		}
+	}
+
+	return v.problems
+}
+
+// checkConditions makes sure "when" conditions compile; they are plain
+// strings to the schema
+func (v *validator) checkConditions(obj map[string]any, path string) {
+	var cfg Config
+	cfg.Sections = obj["sections"]
+	for _, line := range cfg.GetAllSectionLines() {
+		for _, section := range line {
+			if section.When == "" {
+				continue
+			}
+			if _, err := expr.Parse(section.When); err != nil {
+				v.add(joinPath(path, "sections"), fmt.Sprintf("%s: invalid when %q: %v", section.Name, section.When, err))
+			}
+		}
+	}
+}
+
+// ValidateFiles validates every config file that applies to projectDir.
+// Options of installed plugins are also checked against the plugin's own
+// schema.json, when it ships one.
+func ValidateFiles(projectDir string) []Problem {
+	var problems []Problem
+
+	for _, tier := range Tiers(projectDir) {
+		data, err := os.ReadFile(tier.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, Problem{File: tier.Path, Message: err.Error()})
+			continue
+		}
+
+		for _, p := range Validate(data) {
+			p.File = tier.Path
+			problems = append(problems, p)
+		}
+		for _, p := range validatePluginOptions(data) {
+			p.File = tier.Path
+			problems = append(problems, p)
+		}
+	}
+
+	return problems
+}
+
+// validatePluginOptions checks plugins.<name> against PluginsDir()/<name>/schema.json
+func validatePluginOptions(data []byte) []Problem {
+	var doc struct {
+		Plugins map[string]any `json:"plugins"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(doc.Plugins))
+	for name := range doc.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []Problem
+	for _, name := range names {
+		raw, err := os.ReadFile(filepath.Join(PluginsDir(), name, "schema.json"))
+		if err != nil {
+			continue
+		}
+		root, err := decodeSchema(raw)
+		if err != nil {
+			problems = append(problems, Problem{Path: "plugins." + name, Message: fmt.Sprintf("plugin schema: %v", err)})
+			continue
+		}
+		v := validator{root: root}
+		v.check(root, doc.Plugins[name], "plugins."+name)
+		problems = append(problems, v.problems...)
+	}
+	return problems
+}
+
+// describeJSONError adds the line and column to JSON syntax errors
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := 1 + bytes.Count(data[:syntaxErr.Offset], []byte("\n"))
+		col := int(syntaxErr.Offset) - bytes.LastIndexByte(data[:syntaxErr.Offset], '\n') - 1
+		return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, col, err)
+	}
+	return fmt.Sprintf("invalid JSON: %v", err)
+}
+
+var (
+	schemaOnce sync.Once
+	schemaRoot map[string]any
+)
+
+// schema returns the decoded config schema, parsed on first use. It is
+// shared, so callers must not modify it.
+func schema() map[string]any {
+	schemaOnce.Do(func() {
+		schemaRoot, _ = decodeSchema(schemaJSON)
+	})
+	return schemaRoot
+}
+
+func decodeSchema(data []byte) (map[string]any, error) {
+	var root map[string]any
+	err := json.Unmarshal(data, &root)
+	return root, err
+}
+
+// validator implements the subset of JSON Schema the config schema uses:
+// type, enum, properties, additionalProperties, required, items, anyOf,
+// minimum, maximum and local $ref
+type validator struct {
+	root     map[string]any
+	problems []Problem
+}
+
+func (v *validator) add(path, message string) {
+	v.problems = append(v.problems, Problem{Path: path, Message: message})
+}
+
+func (v *validator) check(schema map[string]any, value any, path string) {
+	schema = v.resolve(schema)
+
+	if alternatives, ok := schema["anyOf"].([]any); ok {
+		v.checkAnyOf(alternatives, value, path)
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.add(path, fmt.Sprintf("expected %s, got %s", describeType(t), jsonType(value)))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) && jsonType(allowed) == jsonType(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.add(path, fmt.Sprintf("must be one of %s, got %s", describeEnum(enum), compactJSON(value)))
+			return
+		}
+	}
+
+	switch val := value.(type) {
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && val < min {
+			v.add(path, fmt.Sprintf("must be >= %v, got %v", min, val))
+		}
+		if max, ok := schema["maximum"].(float64); ok && val > max {
+			v.add(path, fmt.Sprintf("must be <= %v, got %v", max, val))
+		}
+
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				v.check(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := val[name]; !present {
+						v.add(path, fmt.Sprintf("missing required key %q", name))
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := joinPath(path, key)
+			propKey := key
+			if strings.HasPrefix(path, "plugins") {
+				propKey = mergeKey(key) // Plugin options may carry merge suffixes
+			}
+			if propSchema, ok := props[propKey].(map[string]any); ok {
+				v.check(propSchema, val[key], keyPath)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					v.add(keyPath, "unknown key"+suggest(key, props))
+				}
+			case map[string]any:
+				v.check(extra, val[key], keyPath)
+			}
+		}
+	}
+}
+
+// checkAnyOf reports an error unless one alternative matches. When only one
+// alternative has the value's type, its errors are reported directly since
+// they are more helpful than a generic message.
+func (v *validator) checkAnyOf(alternatives []any, value any, path string) {
+	var sameType []map[string]any
+	for _, alt := range alternatives {
+		altSchema, ok := alt.(map[string]any)
+		if !ok {
+			continue
+		}
+		altSchema = v.resolve(altSchema)
+		sub := validator{root: v.root}
+		sub.check(altSchema, value, path)
+		if len(sub.problems) == 0 {
+			return
+		}
+		if t, ok := altSchema["type"]; !ok || matchesType(t, value) {
+			sameType = append(sameType, altSchema)
+		}
+	}
+
+	if len(sameType) == 1 {
+		v.check(sameType[0], value, path)
+		return
+	}
+	v.add(path, fmt.Sprintf("unexpected value %s", compactJSON(value)))
+}
+
+// resolve follows local "$ref": "#/$defs/name" references
+func (v *validator) resolve(schema map[string]any) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return schema
+	}
+	var node any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		node = m[part]
+	}
+	if resolved, ok := node.(map[string]any); ok {
+		return resolved
+	}
+	return map[string]any{}
+}
+
+func matchesType(t any, value any) bool {
+	switch tt := t.(type) {
+	case string:
+		actual := jsonType(value)
+		if tt == "number" && actual == "integer" {
+			return true
+		}
+		return tt == actual
+	case []any:
+		for _, one := range tt {
+			if matchesType(one, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, one := range list {
+			names[i] = fmt.Sprint(one)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func describeEnum(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = compactJSON(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggest returns a hint for a misspelled key, e.g. ` (did you mean "sections"?)`
+func suggest(key string, props map[string]any) string {
+	best, bestDist := "", 3
+	for name := range props {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
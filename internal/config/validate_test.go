@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate_Valid(t *testing.T) {
+	data := `{
+		"icon": "💎",
+		"sections": [["dir", {"section": "usage", "when": "context_pct > 50"}], ["git"]],
+		"autocompactBuffer": 0,
+		"theme": "solarized",
+		"colors": {"warning": "208"},
+		"colorMode": "none",
+		"width": 80,
+		"powerline": {"enabled": true, "segments": {"git": {"fg": "black", "bg": "gold"}}},
+		"plugins": {
+			"git": {"format": "{{.branch}}"},
+			"usage": {"api_billing": {"decimals": 3}, "usage_plan!": {"style": "bars"}},
+			"android_devices": {"packages+": ["com.example"]},
+			"my_plugin": {"anything": [1, 2, 3]}
+		}
+	}`
+
+	if problems := Validate([]byte(data)); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	tests := []struct {
+		json string
+		path string
+		want string
+	}{
+		{`{"sectons": []}`, "sectons", `did you mean "sections"`},
+		{`{"sections": "dir"}`, "sections", "expected array, got string"},
+		{`{"sections": ["dir", 42]}`, "sections[1]", "unexpected value 42"},
+		{`{"sections": [{"when": "true"}]}`, "sections[0]", `missing required key "section"`},
+		{`{"sections": [{"section": "git", "when": "is_git_repo and"}]}`, "sections", "invalid when"},
+		{`{"autocompactBuffer": "22"}`, "autocompactBuffer", "expected number, got string"},
+		{`{"width": 80.5}`, "width", "expected integer, got number"},
+		{`{"theme": "neon"}`, "theme", "must be one of"},
+		{`{"plugins": {"usage": {"api_billing": {"decimals": -1}}}}`, "plugins.usage.api_billing.decimals", "must be >= 0"},
+		{`{"plugins": {"android_devices": {"packages": "com.example"}}}`, "plugins.android_devices.packages", "expected array"},
+		{`{"plugins": {"my_plugin": true}}`, "plugins.my_plugin", "expected object"},
+	}
+
+	for _, tt := range tests {
+		problems := Validate([]byte(tt.json))
+		if len(problems) != 1 {
+			t.Errorf("%s: expected 1 problem, got %v", tt.json, problems)
+			continue
+		}
+		if problems[0].Path != tt.path || !strings.Contains(problems[0].Message, tt.want) {
+			t.Errorf("%s: got %q, want path %q containing %q", tt.json, problems[0], tt.path, tt.want)
+		}
+	}
+}
+
+func TestValidate_SyntaxErrorPosition(t *testing.T) {
+	problems := Validate([]byte("{\n  \"icon\": \"x\",\n}"))
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "line 3") {
+		t.Errorf("expected a line 3 syntax error, got %v", problems)
+	}
+}
+
+func TestExplain_ReportsTiers(t *testing.T) {
+	home, err := os.MkdirTemp("", "prism-test-home-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	t.Setenv("HOME", home)
+
+	projectDir := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"),
+		[]byte(`{"icon": "g", "theme": "light", "plugins": {"git": {}}}`), 0644)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.json"),
+		[]byte(`{"theme": "solarized", "plugins": {"usage": {}}}`), 0644)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "prism.local.json"),
+		[]byte(`{"icon": "l"}`), 0644)
+
+	cfg, sources := Explain(projectDir, projectDir)
+
+	if cfg.Icon != "l" || cfg.Theme != "solarized" {
+		t.Errorf("unexpected merged config: %+v", cfg)
+	}
+
+	expected := map[string]string{
+		"icon":          "local",
+		"theme":         "project",
+		"plugins.git":   "global",
+		"plugins.usage": "project",
+	}
+	for key, tier := range expected {
+		if sources[key].Name != tier {
+			t.Errorf("%s: expected tier %q, got %q", key, tier, sources[key].Name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/himattm/prism/internal/logging"
+)
+
+// Watcher reports changes to files in watched directories
+type Watcher interface {
+	// Add starts watching the files directly inside dir
+	Add(dir string) error
+	Close() error
+}
+
+// NewWatcher returns a watcher driven by the OS's file events (inotify on
+// Linux, kqueue on macOS and the BSDs), falling back to polling where they
+// are unavailable or cannot be set up (e.g. the inotify instance limit)
+func NewWatcher(onChange func(path string)) (Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		logging.Debug("config.watch", "error", err, "fallback", "polling")
+		return newPollWatcher(onChange), nil
+	}
+	w := &notifyWatcher{watcher: fw, onChange: onChange}
+	go w.loop()
+	return w, nil
+}
+
+// notifyWatcher reports file events from fsnotify
+type notifyWatcher struct {
+	watcher  *fsnotify.Watcher
+	onChange func(path string)
+}
+
+func (w *notifyWatcher) Add(dir string) error {
+	return w.watcher.Add(dir)
+}
+
+func (w *notifyWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+func (w *notifyWatcher) loop() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Editors often save by writing a temp file and renaming it
+			// into place, so creates, renames and removes count too
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			w.onChange(event.Name)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.Debug("config.watch", "error", err)
+		}
+	}
+}
+
+// pollInterval is how often the polling watcher rescans its directories
+const pollInterval = 1 * time.Second
+
+// pollWatcher detects changes by rescanning directories. It is used where
+// file events are not available.
+type pollWatcher struct {
+	onChange func(path string)
+
+	mu    sync.Mutex
+	dirs  map[string]map[string]fileStamp // dir -> file name -> stamp
+	done  chan struct{}
+	close sync.Once
+}
+
+func newPollWatcher(onChange func(path string)) *pollWatcher {
+	w := &pollWatcher{
+		onChange: onChange,
+		dirs:     make(map[string]map[string]fileStamp),
+		done:     make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+func (w *pollWatcher) Add(dir string) error {
+	files, err := scanDir(dir)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.dirs[dir] = files
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *pollWatcher) Close() error {
+	w.close.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *pollWatcher) loop() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			for _, path := range w.scan() {
+				w.onChange(path)
+			}
+		}
+	}
+}
+
+// scan returns the paths that were created, changed or removed since the last scan
+func (w *pollWatcher) scan() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for dir, before := range w.dirs {
+		after, err := scanDir(dir)
+		if err != nil {
+			after = map[string]fileStamp{}
+		}
+		for name, stamp := range after {
+			if prev, ok := before[name]; !ok || !prev.equal(stamp) {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		for name := range before {
+			if _, ok := after[name]; !ok {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		w.dirs[dir] = after
+	}
+	return changed
+}
+
+func scanDir(dir string) (map[string]fileStamp, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fileStamp, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files[entry.Name()] = fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/statusline"
+	"github.com/himattm/prism/internal/version"
+)
+
+const (
+	dialTimeout    = 50 * time.Millisecond
+	requestTimeout = 2 * time.Second
+)
+
+// Render asks a running daemon to render the status line for the raw
+// Claude Code JSON input, for this process's terminal (see
+// statusline.TerminalEnv) and with its PRISM_* and --set overrides. It fails
+// fast when no daemon is listening, or when the daemon runs a different prism
+// version (e.g. after an auto-update), so callers can fall back to rendering
+// in-process.
+func Render(input []byte) (string, error) {
+	resp, err := send(SocketPath(), Request{
+		Command:   "render",
+		Input:     input,
+		Env:       statusline.TerminalEnv(),
+		Overrides: config.Overrides(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Version != version.Version {
+		return "", fmt.Errorf("daemon version %s does not match %s", resp.Version, version.Version)
+	}
+	return resp.Output, nil
+}
+
+// Hook passes a hook event to the persistent plugins running in the daemon
+// and returns their notifications. input is the raw Claude Code hook JSON.
+func Hook(hookType string, input []byte) (string, error) {
+	resp, err := send(SocketPath(), Request{Command: "hook", Hook: hookType, Input: input, Overrides: config.Overrides()})
+	if err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// Ping returns the version of the running daemon
+func Ping() (string, error) {
+	return ping(SocketPath())
+}
+
+// Stop asks the running daemon to shut down
+func Stop() error {
+	_, err := send(SocketPath(), Request{Command: "stop"})
+	return err
+}
+
+func ping(socketPath string) (string, error) {
+	resp, err := send(socketPath, Request{Command: "ping"})
+	if err != nil {
+		return "", err
+	}
+	return resp.Version, nil
+}
+
+func send(socketPath string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+	if err := checkPeer(conn); err != nil {
+		return Response{}, fmt.Errorf("refusing daemon socket %s: %w", socketPath, err)
+	}
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,261 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/logging"
+	"github.com/himattm/prism/internal/statusline"
+	"github.com/himattm/prism/internal/version"
+)
+
+const (
+	// refreshCheck is how often the refresh loop looks for workspaces that
+	// are due; it renders nothing unless one is
+	refreshCheck = 1 * time.Second
+
+	// refreshAfter is how long after a request a workspace is re-rendered in
+	// the background: when its git data (cache.GitTTL) has just expired
+	refreshAfter = cache.GitTTL
+
+	// maxRefreshBackoff caps the doubling delay between background refreshes
+	// of a workspace that has had no requests since
+	maxRefreshBackoff = 2 * time.Minute
+
+	// workspaceTTL is how long a workspace keeps being refreshed after its
+	// last status line request
+	workspaceTTL = 10 * time.Minute
+)
+
+// Request is sent by clients over the socket (one JSON object per connection)
+type Request struct {
+	Command string            `json:"command"` // "render", "hook", "ping" or "stop"
+	Input   json.RawMessage   `json:"input,omitempty"`
+	Hook    string            `json:"hook,omitempty"` // Hook type for "hook", e.g. "idle"
+	Env     map[string]string `json:"env"`            // Client's terminal environment for "render" (see statusline.TerminalEnv)
+
+	// Overrides are the client's PRISM_* variables and --set flags. The
+	// daemon applies them instead of its own for "render" and "hook".
+	Overrides []config.Override `json:"overrides,omitempty"`
+}
+
+// Response is the daemon's reply to a Request
+type Response struct {
+	Output  string `json:"output,omitempty"`
+	Version string `json:"version"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Server renders status lines for clients using a long-lived engine
+type Server struct {
+	engine  *statusline.Engine
+	configs *config.Cache
+	changed chan struct{} // Signals a config edit to the refresh loop
+
+	mu         sync.Mutex
+	workspaces map[string]workspace // Keyed by project dir
+}
+
+// workspace remembers the last request seen for a project so it can be
+// refreshed. Each background refresh without a new request doubles backoff.
+type workspace struct {
+	input     statusline.Input
+	env       map[string]string
+	overrides []config.Override
+	lastSeen  time.Time
+	next      time.Time     // When the next background refresh is due
+	backoff   time.Duration // Delay up to next, doubled by each background refresh
+}
+
+// NewServer creates a daemon server with a fresh engine
+func NewServer() *Server {
+	return &Server{
+		engine:     statusline.NewEngine(),
+		configs:    config.NewCache(),
+		changed:    make(chan struct{}, 1),
+		workspaces: make(map[string]workspace),
+	}
+}
+
+// Serve listens on the socket until ctx is cancelled or a client sends "stop"
+func (s *Server) Serve(ctx context.Context, socketPath string) error {
+	// Only this user may be able to bind or replace the socket
+	if err := cache.PrivateDir(filepath.Dir(socketPath)); err != nil {
+		return fmt.Errorf("unsafe socket directory: %w", err)
+	}
+
+	// Refuse to start twice; clean up a stale socket from a crashed daemon
+	if _, err := ping(socketPath); err == nil {
+		return fmt.Errorf("daemon already running on %s", socketPath)
+	}
+	os.Remove(socketPath)
+	s.configureLogging()
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+	defer s.engine.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	// Pick up a new log level when a config file is edited
+	s.configs.Watch(ctx, func() {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	})
+
+	go s.refreshLoop(ctx)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn, cancel)
+	}
+}
+
+func (s *Server) handle(conn net.Conn, stop context.CancelFunc) {
+	defer conn.Close()
+	if err := checkPeer(conn); err != nil {
+		logging.Warn("daemon", "error", fmt.Sprintf("refused connection: %v", err))
+		return
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		logging.Warn("daemon", "error", fmt.Sprintf("bad request: %v", err))
+		writeResponse(conn, Response{Error: fmt.Sprintf("bad request: %v", err)})
+		return
+	}
+
+	switch req.Command {
+	case "ping":
+		writeResponse(conn, Response{})
+	case "stop":
+		writeResponse(conn, Response{})
+		stop()
+	case "render":
+		var input statusline.Input
+		if err := json.Unmarshal(req.Input, &input); err != nil {
+			logging.Warn("daemon", "error", fmt.Sprintf("bad input: %v", err))
+			writeResponse(conn, Response{Error: fmt.Sprintf("bad input: %v", err)})
+			return
+		}
+		s.remember(input, req.Env, req.Overrides, time.Now())
+		writeResponse(conn, Response{Output: s.render(input, req.Env, req.Overrides)})
+	case "hook":
+		var input struct {
+			SessionID string `json:"session_id"`
+		}
+		json.Unmarshal(req.Input, &input) // Hooks run without a session ID too
+		outputs := s.engine.Hook(req.Hook, input.SessionID, s.configs.LoadWorkspace("", "", req.Overrides))
+		writeResponse(conn, Response{Output: strings.Join(outputs, "\n")})
+	default:
+		writeResponse(conn, Response{Error: fmt.Sprintf("unknown command: %s", req.Command)})
+	}
+}
+
+// render renders for the client's terminal: its colors and width, not the
+// daemon's (env nil means the daemon's own), with the client's overrides
+func (s *Server) render(input statusline.Input, env map[string]string, overrides []config.Override) string {
+	cfg := s.configs.LoadWorkspace(input.Workspace.ProjectDir, input.Workspace.CurrentDir, overrides)
+	sl := s.engine.New(input, cfg)
+	sl.SetEnv(env)
+	return sl.Render()
+}
+
+// remember records a request and schedules the workspace's next background
+// refresh for when the data just rendered goes stale
+func (s *Server) remember(input statusline.Input, env map[string]string, overrides []config.Override, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspaces[input.Workspace.ProjectDir] = workspace{
+		input:     input,
+		env:       env,
+		overrides: overrides,
+		lastSeen:  now,
+		next:      now.Add(refreshAfter),
+		backoff:   refreshAfter,
+	}
+}
+
+// refreshLoop re-renders workspaces whose cached data has expired so it is
+// warm by the time Claude Code asks for the next status line. Workspaces
+// that stop asking are refreshed less and less often, then forgotten.
+func (s *Server) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(refreshCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+			s.configureLogging()
+		case now := <-ticker.C:
+			for _, ws := range s.dueWorkspaces(now) {
+				s.render(ws.input, ws.env, ws.overrides)
+			}
+		}
+	}
+}
+
+// configureLogging sets the log level from the global config. The level is
+// process-wide, so a project's log_level does not apply in the daemon.
+func (s *Server) configureLogging() {
+	logging.Configure(s.configs.Load("").LogLevel)
+}
+
+// dueWorkspaces returns the workspaces whose refresh is due at now and
+// schedules their next one, backing off. Workspaces idle for longer than
+// workspaceTTL are dropped.
+func (s *Server) dueWorkspaces(now time.Time) []workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []workspace
+	for dir, ws := range s.workspaces {
+		if now.Sub(ws.lastSeen) > workspaceTTL {
+			delete(s.workspaces, dir)
+			continue
+		}
+		if now.Before(ws.next) {
+			continue
+		}
+		due = append(due, ws)
+		ws.backoff = min(2*ws.backoff, maxRefreshBackoff)
+		ws.next = now.Add(ws.backoff)
+		s.workspaces[dir] = ws
+	}
+	return due
+}
+
+func writeResponse(conn net.Conn, resp Response) {
+	resp.Version = version.Version
+	json.NewEncoder(conn).Encode(resp)
+}
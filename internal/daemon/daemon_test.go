@@ -0,0 +1,259 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/logging"
+	"github.com/himattm/prism/internal/statusline"
+	"github.com/himattm/prism/internal/version"
+)
+
+func startTestServer(t *testing.T) (string, <-chan error) {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "prism-daemon-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	socketPath := filepath.Join(tmpDir, "prism.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	done := make(chan error, 1)
+	go func() { done <- NewServer().Serve(ctx, socketPath) }()
+
+	// Wait for the socket to accept connections
+	for i := 0; i < 50; i++ {
+		if _, err := ping(socketPath); err == nil {
+			return socketPath, done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("daemon did not start")
+	return "", nil
+}
+
+func TestServer_PingReturnsVersion(t *testing.T) {
+	socketPath, _ := startTestServer(t)
+
+	ver, err := ping(socketPath)
+	if err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	if ver != version.Version {
+		t.Errorf("expected version %s, got %s", version.Version, ver)
+	}
+}
+
+func TestServer_RefusesSecondInstance(t *testing.T) {
+	socketPath, _ := startTestServer(t)
+
+	err := NewServer().Serve(context.Background(), socketPath)
+	if err == nil {
+		t.Error("expected error when a daemon is already listening")
+	}
+}
+
+func TestServer_RefusesSharedSocketDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shared")
+	os.Mkdir(dir, 0777)
+	os.Chmod(dir, 0777)
+
+	err := NewServer().Serve(context.Background(), filepath.Join(dir, "prism.sock"))
+	if err == nil || !strings.Contains(err.Error(), "unsafe socket directory") {
+		t.Errorf("expected a world-writable socket directory to be refused, got %v", err)
+	}
+}
+
+func TestCheckPeer_SameUser(t *testing.T) {
+	socketPath, _ := startTestServer(t)
+
+	// Both ends run as this user, and both check the other
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := checkPeer(conn); err != nil {
+		t.Errorf("checkPeer: %v", err)
+	}
+}
+
+func TestServer_StopCommand(t *testing.T) {
+	socketPath, done := startTestServer(t)
+
+	if _, err := send(socketPath, Request{Command: "stop"}); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("socket file should be removed on shutdown")
+	}
+}
+
+func TestServer_BadRenderInput(t *testing.T) {
+	socketPath, _ := startTestServer(t)
+
+	_, err := send(socketPath, Request{Command: "render", Input: []byte(`"not an object"`)})
+	if err == nil {
+		t.Error("expected error for malformed input")
+	}
+}
+
+func TestRender_NoDaemon(t *testing.T) {
+	_, err := send(filepath.Join(os.TempDir(), "prism-missing-test.sock"), Request{Command: "ping"})
+	if err == nil {
+		t.Error("expected error when no daemon is listening")
+	}
+}
+
+func TestServer_HookReachesPersistentPlugins(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pluginDir := filepath.Join(home, ".claude", "prism-plugins")
+	os.MkdirAll(pluginDir, 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"), []byte(`{"sections": ["echo"]}`), 0644)
+	os.WriteFile(filepath.Join(pluginDir, "prism-plugin-echo.sh"), []byte(`#!/bin/sh
+# @protocol persistent
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+  case "$line" in
+    *'"method":"hook"'*) printf '{"jsonrpc":"2.0","id":%s,"result":"hook seen"}\n' "$id" ;;
+    *) printf '{"jsonrpc":"2.0","id":%s,"result":"echo"}\n' "$id" ;;
+  esac
+done
+`), 0755)
+
+	socketPath, _ := startTestServer(t)
+
+	// Nothing is running before the first render, so there is nothing to notify
+	resp, err := send(socketPath, Request{Command: "hook", Hook: "idle"})
+	if err != nil || resp.Output != "" {
+		t.Fatalf("hook before render = %q, %v; want no output", resp.Output, err)
+	}
+
+	input := []byte(`{"workspace": {"project_dir": "` + home + `", "current_dir": "` + home + `"}}`)
+	if resp, err := send(socketPath, Request{Command: "render", Input: input}); err != nil || !strings.Contains(resp.Output, "echo") {
+		t.Fatalf("render = %q, %v", resp.Output, err)
+	}
+
+	resp, err = send(socketPath, Request{Command: "hook", Hook: "idle", Input: []byte(`{"session_id": "abc"}`)})
+	if err != nil || resp.Output != "hook seen" {
+		t.Errorf("hook after render = %q, %v; want the plugin's notification", resp.Output, err)
+	}
+}
+
+func TestServer_RendersForClientTerminal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"), []byte(`{"sections": ["model"]}`), 0644)
+	t.Setenv("NO_COLOR", "")
+	socketPath, _ := startTestServer(t)
+
+	input := []byte(`{"model": {"display_name": "Opus"}, "workspace": {"project_dir": "` + home + `"}}`)
+	render := func(env map[string]string) string {
+		resp, err := send(socketPath, Request{Command: "render", Input: input, Env: env})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Output
+	}
+
+	if got := render(map[string]string{"NO_COLOR": "1"}); got != "Opus" {
+		t.Errorf("client with NO_COLOR got %q, want no escape codes", got)
+	}
+	if got := render(map[string]string{"TERM": "xterm-256color"}); !strings.Contains(got, "\x1b[") {
+		t.Errorf("client with colors got %q, want escape codes", got)
+	}
+}
+
+func TestServer_LogLevelFromGlobalConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"), []byte(`{"log_level": "info", "sections": ["model"]}`), 0644)
+	project := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(project, ".claude"), 0755)
+	os.WriteFile(filepath.Join(project, ".claude", "prism.json"), []byte(`{"log_level": "off"}`), 0644)
+	t.Cleanup(func() { logging.SetLevel(logging.LevelOff) })
+	socketPath, _ := startTestServer(t)
+
+	// A project's log_level must not switch the daemon's log off
+	input := []byte(`{"model": {"display_name": "Opus"}, "workspace": {"project_dir": "` + project + `"}}`)
+	if _, err := send(socketPath, Request{Command: "render", Input: input}); err != nil {
+		t.Fatal(err)
+	}
+	if !logging.Enabled(logging.LevelInfo) {
+		t.Error("log level changed by a project's config")
+	}
+}
+
+func TestServer_AppliesClientOverrides(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "prism-config.json"), []byte(`{"sections": ["model"]}`), 0644)
+	socketPath, _ := startTestServer(t)
+
+	input := []byte(`{"model": {"display_name": "Opus"}, "workspace": {"project_dir": "` + home + `"}}`)
+	overrides := []config.Override{{Key: "sections", Value: "dir", Source: "PRISM_SECTIONS"}}
+	resp, err := send(socketPath, Request{Command: "render", Input: input, Env: map[string]string{"NO_COLOR": "1"}, Overrides: overrides})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(resp.Output, "Opus") {
+		t.Errorf("render = %q, want the client's sections override applied", resp.Output)
+	}
+}
+
+func TestServer_RefreshBacksOffWhenIdle(t *testing.T) {
+	s := NewServer()
+	defer s.engine.Close()
+	start := time.Now()
+	s.remember(statusline.Input{}, nil, nil, start)
+
+	due := func(after time.Duration) bool {
+		return len(s.dueWorkspaces(start.Add(after))) == 1
+	}
+	if due(refreshAfter - time.Millisecond) {
+		t.Error("refreshed before the data expired")
+	}
+	if !due(refreshAfter) {
+		t.Fatal("not refreshed once the data expired")
+	}
+	if due(2*refreshAfter) || !due(3*refreshAfter) {
+		t.Error("second refresh not backed off to twice the delay")
+	}
+	if due(6*refreshAfter) || !due(7*refreshAfter) {
+		t.Error("third refresh not backed off to four times the delay")
+	}
+
+	// A request resets the schedule
+	s.remember(statusline.Input{}, nil, nil, start.Add(8*refreshAfter))
+	if due(8*refreshAfter+refreshAfter/2) || !due(9*refreshAfter) {
+		t.Error("request did not reset the backoff")
+	}
+
+	if due(workspaceTTL + time.Minute); len(s.workspaces) != 0 {
+		t.Error("idle workspace not forgotten")
+	}
+}
@@ -0,0 +1,12 @@
+package daemon
+
+import "golang.org/x/sys/unix"
+
+// peerUID returns the user ID of the process at the other end of a Unix socket
+func peerUID(fd int) (int, error) {
+	cred, err := unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+	if err != nil {
+		return 0, err
+	}
+	return int(cred.Uid), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "fmt"
+
+// peerUID is not implemented here, so the daemon refuses every connection
+func peerUID(fd int) (int, error) {
+	return 0, fmt.Errorf("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// SocketPath returns the per-user Unix socket the daemon listens on: in
+// $XDG_RUNTIME_DIR when set, else in a prism-<uid> directory in the temp dir.
+// Either way its directory must be private to the user (see cache.PrivateDir).
+func SocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "prism", "prism.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("prism-%d", os.Getuid()), "prism.sock")
+}
+
+// checkPeer refuses a connection whose other end runs as another user.
+// The server checks its clients, and clients check the server, so neither
+// side trusts a socket someone else managed to bind.
+func checkPeer(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("not a Unix socket")
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var uid int
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		uid, credErr = peerUID(int(fd))
+	}); err != nil {
+		return err
+	}
+	if credErr != nil {
+		return fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("peer runs as uid %d, not as you (uid %d)", uid, os.Getuid())
+	}
+	return nil
+}
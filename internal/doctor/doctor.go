@@ -0,0 +1,334 @@
+// Package doctor checks the tools, credentials and settings Prism depends on,
+// for `prism doctor`
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/plugin"
+	"github.com/himattm/prism/internal/plugins"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	OK   Status = iota
+	Warn        // Works, with reduced functionality
+	Fail        // Broken: some sections will be missing or wrong
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Hint   string // How to fix it, when not OK
+}
+
+// Replaced in tests
+var (
+	lookPath   = exec.LookPath
+	oauthToken = plugins.GetOAuthToken
+)
+
+// Run performs every check for projectDir
+func Run(projectDir string) []Result {
+	cfg := config.LoadWorkspace(projectDir, projectDir)
+
+	var results []Result
+	results = append(results, checkGit())
+	results = append(results, checkADB(cfg))
+	results = append(results, checkCredentials())
+	results = append(results, checkSettings()...)
+	results = append(results, checkPlugins(cfg)...)
+	if r, ok := checkLock(plugin.NewManager(), projectDir); ok {
+		results = append(results, r)
+	}
+	results = append(results, checkConfig(projectDir)...)
+	return results
+}
+
+func checkGit() Result {
+	path, err := lookPath("git")
+	if err != nil {
+		return Result{
+			Name:   "git",
+			Status: Fail,
+			Detail: "not found on PATH",
+			Hint:   "install git; the git and linesChanged sections need it",
+		}
+	}
+	return Result{Name: "git", Status: OK, Detail: path}
+}
+
+func checkADB(cfg config.Config) Result {
+	path, err := lookPath("adb")
+	if err == nil {
+		return Result{Name: "adb", Status: OK, Detail: path}
+	}
+	if !usesSection(cfg, "android_devices") {
+		return Result{Name: "adb", Status: OK, Detail: "not found, not needed (no android_devices section)"}
+	}
+	return Result{
+		Name:   "adb",
+		Status: Fail,
+		Detail: "not found on PATH, but the android_devices section is configured",
+		Hint:   "install the Android SDK platform-tools and add them to PATH",
+	}
+}
+
+func checkCredentials() Result {
+	if _, err := oauthToken(); err != nil {
+		return Result{
+			Name:   "credentials",
+			Status: Warn,
+			Detail: fmt.Sprintf("no Claude OAuth token (%v)", err),
+			Hint:   "expected with API billing: usage shows the session cost. On a Max/Pro plan, log in with Claude Code to show plan limits",
+		}
+	}
+	return Result{Name: "credentials", Status: OK, Detail: "Claude OAuth token readable, usage shows plan limits"}
+}
+
+// claudeSettings is the part of ~/.claude/settings.json Prism is wired into
+type claudeSettings struct {
+	StatusLine *struct {
+		Command string `json:"command"`
+	} `json:"statusLine"`
+	Hooks map[string][]struct {
+		Hooks []struct {
+			Command string `json:"command"`
+		} `json:"hooks"`
+	} `json:"hooks"`
+}
+
+// expectedHooks maps Claude Code hook events to the prism hook they should run
+var expectedHooks = []struct{ event, hook string }{
+	{"UserPromptSubmit", "busy"},
+	{"Stop", "idle"},
+}
+
+func checkSettings() []Result {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return []Result{{Name: "settings", Status: Fail, Detail: err.Error()}}
+	}
+	path := filepath.Join(home, ".claude", "settings.json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []Result{{
+			Name:   "settings",
+			Status: Fail,
+			Detail: fmt.Sprintf("cannot read %s", path),
+			Hint:   "run install.sh, or add the statusLine and hooks entries shown in the README",
+		}}
+	}
+	var settings claudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return []Result{{Name: "settings", Status: Fail, Detail: fmt.Sprintf("%s: %v", path, err)}}
+	}
+
+	var results []Result
+	if settings.StatusLine == nil || !strings.Contains(settings.StatusLine.Command, "prism") {
+		results = append(results, Result{
+			Name:   "statusLine",
+			Status: Fail,
+			Detail: fmt.Sprintf("%s does not run prism as the status line", path),
+			Hint:   `set "statusLine": {"type": "command", "command": "$HOME/.claude/prism"}`,
+		})
+	} else {
+		results = append(results, Result{Name: "statusLine", Status: OK, Detail: settings.StatusLine.Command})
+	}
+
+	for _, expected := range expectedHooks {
+		name := "hook " + expected.hook
+		want := "prism hook " + expected.hook
+		found := false
+		for _, matcher := range settings.Hooks[expected.event] {
+			for _, h := range matcher.Hooks {
+				if strings.Contains(h.Command, want) {
+					found = true
+				}
+			}
+		}
+		if found {
+			results = append(results, Result{Name: name, Status: OK, Detail: expected.event})
+			continue
+		}
+		results = append(results, Result{
+			Name:   name,
+			Status: Warn,
+			Detail: fmt.Sprintf("no %s hook runs %q", expected.event, want),
+			Hint:   "without idle/busy hooks Prism cannot tell when Claude is working, so slow refreshes may run mid-response",
+		})
+	}
+	return results
+}
+
+func checkPlugins(cfg config.Config) []Result {
+	manager := plugin.NewManager()
+	discovered, err := manager.Discover()
+	if err != nil {
+		return []Result{{Name: "plugins", Status: Fail, Detail: err.Error()}}
+	}
+
+	var results []Result
+	for _, p := range discovered {
+		name := "plugin " + p.Name
+		info, err := os.Stat(p.Path)
+		if err != nil {
+			results = append(results, Result{Name: name, Status: Fail, Detail: err.Error()})
+			continue
+		}
+		if info.Mode()&0111 == 0 {
+			results = append(results, Result{
+				Name:   name,
+				Status: Fail,
+				Detail: fmt.Sprintf("%s is not executable", p.Path),
+				Hint:   fmt.Sprintf("chmod +x %s", p.Path),
+			})
+			continue
+		}
+		if err := p.Metadata.Compatible(); err != nil {
+			results = append(results, Result{
+				Name:   name,
+				Status: Fail,
+				Detail: err.Error(),
+				Hint:   fmt.Sprintf("run 'prism plugin update %s' or remove it", p.Name),
+			})
+			continue
+		}
+		if missing := p.Metadata.MissingCommands(); len(missing) > 0 {
+			results = append(results, Result{
+				Name:   name,
+				Status: Fail,
+				Detail: fmt.Sprintf("needs %s on PATH", strings.Join(missing, ", ")),
+				Hint:   "install it; the section stays hidden until then",
+			})
+			continue
+		}
+		if err := manager.CheckIntegrity(p); err != nil {
+			results = append(results, Result{
+				Name:   name,
+				Status: Fail,
+				Detail: err.Error(),
+				Hint:   fmt.Sprintf("reinstall it from %s, or remove it", p.Metadata.Source),
+			})
+			continue
+		}
+		if cfg.RequireVerifiedPlugins() && p.Metadata.Verified == "" {
+			results = append(results, Result{
+				Name:   name,
+				Status: Fail,
+				Detail: "not verified, and pluginTrust.requireVerified is set",
+				Hint:   "reinstall it from a release that publishes checksums, or remove it",
+			})
+			continue
+		}
+		if problems := p.Metadata.ValidateConfig(cfg.LoadPluginConfig(p.Name)); len(problems) > 0 {
+			results = append(results, Result{
+				Name:   name,
+				Status: Warn,
+				Detail: "config " + strings.Join(problems, "; "),
+				Hint:   fmt.Sprintf("fix plugins.%s in your config; see the plugin's %s", p.Name, plugin.ManifestFile),
+			})
+			continue
+		}
+		detail := p.Path
+		if p.Metadata.Version != "" {
+			detail = fmt.Sprintf("v%s, %s", p.Metadata.Version, p.Path)
+		}
+		if p.Metadata.Verified != "" {
+			detail += ", " + p.Metadata.Verified + " verified"
+		}
+		results = append(results, Result{Name: name, Status: OK, Detail: detail})
+	}
+	return results
+}
+
+// checkLock compares the installed plugins with the project's lockfile, if
+// it has one
+func checkLock(manager *plugin.Manager, projectDir string) (Result, bool) {
+	path := plugin.LockPath(projectDir)
+	lock, err := plugin.ReadLock(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Result{}, false
+	}
+	if err != nil {
+		return Result{Name: "plugin lock", Status: Fail, Detail: err.Error()}, true
+	}
+	diff, err := manager.Diff(lock)
+	if err != nil {
+		return Result{Name: "plugin lock", Status: Fail, Detail: err.Error()}, true
+	}
+	if !diff.InSync() {
+		var problems []string
+		for _, lp := range diff.Install {
+			problems = append(problems, lp.Name+" missing or not as pinned")
+		}
+		return Result{
+			Name:   "plugin lock",
+			Status: Warn,
+			Detail: strings.Join(problems, "; "),
+			Hint:   "run 'prism plugin sync' to install the project's plugins",
+		}, true
+	}
+	detail := fmt.Sprintf("%d plugin(s) as pinned in %s", len(diff.Current), path)
+	if len(diff.Remove) > 0 {
+		// Fine for other projects; 'prism plugin sync --prune' removes them
+		detail += fmt.Sprintf(", %d installed from a URL but not pinned", len(diff.Remove))
+	}
+	return Result{Name: "plugin lock", Status: OK, Detail: detail}, true
+}
+
+func checkConfig(projectDir string) []Result {
+	var results []Result
+	for _, tier := range config.Tiers(projectDir) {
+		data, err := os.ReadFile(tier.Path)
+		if err != nil {
+			continue
+		}
+		for _, change := range config.Deprecations(data) {
+			results = append(results, Result{
+				Name:   "config",
+				Status: Warn,
+				Detail: fmt.Sprintf("%s: %s", tier.Path, change),
+				Hint:   "run 'prism config migrate'",
+			})
+		}
+	}
+
+	problems := append(config.ValidateFiles(projectDir), config.ValidateOverrides()...)
+	for _, p := range problems {
+		results = append(results, Result{
+			Name:   "config",
+			Status: Fail,
+			Detail: p.String(),
+			Hint:   "see 'prism config validate'",
+		})
+	}
+	if len(results) == 0 {
+		results = append(results, Result{Name: "config", Status: OK, Detail: "valid"})
+	}
+	return results
+}
+
+// usesSection reports whether name appears on any status line
+func usesSection(cfg config.Config, name string) bool {
+	for _, line := range cfg.GetAllSectionLines() {
+		for _, section := range line {
+			if section.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/plugin"
+)
+
+func statuses(results []Result) map[string]Status {
+	out := make(map[string]Status)
+	for _, r := range results {
+		out[r.Name] = r.Status
+	}
+	return out
+}
+
+func TestCheckADB(t *testing.T) {
+	saved := lookPath
+	t.Cleanup(func() { lookPath = saved })
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	if r := checkADB(config.Config{Sections: []any{"dir"}}); r.Status != OK {
+		t.Errorf("adb is not needed without android_devices, got %+v", r)
+	}
+	cfg := config.Config{Sections: []any{[]any{"dir"}, []any{"android_devices"}}}
+	if r := checkADB(cfg); r.Status != Fail {
+		t.Errorf("expected a failure with android_devices configured, got %+v", r)
+	}
+}
+
+func TestCheckSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if got := statuses(checkSettings()); got["settings"] != Fail {
+		t.Errorf("expected a failure without settings.json, got %v", got)
+	}
+
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	settings := `{
+  "statusLine": {"type": "command", "command": "$HOME/.claude/prism"},
+  "hooks": {"Stop": [{"hooks": [{"type": "command", "command": "$HOME/.claude/prism hook idle"}]}]}
+}`
+	os.WriteFile(filepath.Join(home, ".claude", "settings.json"), []byte(settings), 0644)
+
+	got := statuses(checkSettings())
+	want := map[string]Status{"statusLine": OK, "hook idle": OK, "hook busy": Warn}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: expected status %d, got %d", name, status, got[name])
+		}
+	}
+}
+
+func TestCheckPlugins(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "prism-plugins")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-ok.sh"), []byte("#!/bin/sh\n# @version 1.0.0\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-broken.sh"), []byte("#!/bin/sh\n"), 0644)
+
+	// Manifests installed as sidecars
+	os.WriteFile(filepath.Join(dir, "prism-plugin-future.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-future.sh.json"), []byte(`{"name": "future", "min_prism": "99.0.0"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-xcode.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-xcode.sh.json"), []byte(`{"name": "xcode", "requires": ["prism-no-such-command"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-typed.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-typed.sh.json"), []byte(`{"name": "typed", "config": {"limit": {"type": "number"}}}`), 0644)
+
+	cfg := config.Config{Plugins: map[string]any{"typed": map[string]any{"limit": "ten"}}}
+	got := statuses(checkPlugins(cfg))
+	want := map[string]Status{
+		"plugin ok":     OK,
+		"plugin broken": Fail,
+		"plugin future": Fail,
+		"plugin xcode":  Fail,
+		"plugin typed":  Warn,
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: expected status %d, got %d (all: %v)", name, status, got[name], got)
+		}
+	}
+}
+
+func TestCheckLock(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "prism-plugins")
+	os.MkdirAll(dir, 0755)
+	project := filepath.Join(home, "project")
+
+	if _, ok := checkLock(plugin.NewManager(), project); ok {
+		t.Error("expected no result without a lockfile")
+	}
+
+	lock := &plugin.Lock{Plugins: []plugin.LockedPlugin{{Name: "ci", Source: "https://example.com/prism-plugin-ci.sh"}}}
+	if err := lock.Write(plugin.LockPath(project)); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := checkLock(plugin.NewManager(), project); r.Status != Warn || r.Hint == "" {
+		t.Errorf("expected a warning for a missing plugin, got %+v", r)
+	}
+
+	script := "#!/bin/sh\n# @version 1.0.0\n"
+	os.WriteFile(filepath.Join(dir, "prism-plugin-ci.sh"), []byte(script), 0755)
+	os.WriteFile(filepath.Join(dir, "prism-plugin-ci.sh.json"), []byte(`{"name": "ci", "source": "https://example.com/prism-plugin-ci.sh"}`), 0644)
+	if r, _ := checkLock(plugin.NewManager(), project); r.Status != OK {
+		t.Errorf("expected OK once installed, got %+v", r)
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	saved := oauthToken
+	t.Cleanup(func() { oauthToken = saved })
+
+	oauthToken = func() (string, error) { return "", errors.New("no credentials file") }
+	if r := checkCredentials(); r.Status != Warn || r.Hint == "" {
+		t.Errorf("expected a warning with a hint, got %+v", r)
+	}
+	oauthToken = func() (string, error) { return "token", nil }
+	if r := checkCredentials(); r.Status != OK {
+		t.Errorf("expected OK, got %+v", r)
+	}
+}
@@ -0,0 +1,469 @@
+// Package expr evaluates the small boolean expressions used in "when"
+// conditions of config sections, for example:
+//
+//	context_pct > 50
+//	project has build.gradle
+//	is_git_repo and not (model contains "Haiku")
+package expr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Env is what expressions can refer to
+type Env struct {
+	Vars       map[string]any // Identifiers: bool, int, float64 or string values
+	ProjectDir string         // Base for "project has <path>"
+	CurrentDir string         // Base for "cwd has <path>"
+}
+
+// Expr is a parsed expression
+type Expr struct {
+	root node
+}
+
+// Parse compiles an expression. Grammar, loosest binding first:
+//
+//	or:       and ("or" | "||") and ...
+//	and:      not ("and" | "&&") not ...
+//	not:      ("not" | "!") not | compare
+//	compare:  value [("==" | "!=" | ">" | ">=" | "<" | "<=" | "contains") value]
+//	value:    number | "string" | 'string' | true | false | identifier
+//	          | (project | cwd) has <path or glob> | "(" or ")"
+func Parse(src string) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Expr{root: root}, nil
+}
+
+// Eval evaluates the expression and reports whether it is truthy
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// Eval parses and evaluates src in one step
+func Eval(src string, env Env) (bool, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(env)
+}
+
+// --- Tokens ---
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	rs := []rune(src)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != r {
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokString, string(rs[i+1 : j])})
+			i = j + 1
+
+		case strings.ContainsRune("=!<>&|", r):
+			j := i + 1
+			if j < len(rs) && strings.ContainsRune("=&|", rs[j]) {
+				j++
+			}
+			op := string(rs[i:j])
+			switch op {
+			case "==", "!=", "<", "<=", ">", ">=", "!", "&&", "||":
+			default:
+				return nil, fmt.Errorf("unknown operator %q", op)
+			}
+			tokens = append(tokens, token{tokOp, op})
+			i = j
+
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, string(rs[i:j])})
+			i = j
+
+		default:
+			// Words: identifiers, keywords and bare paths like build.gradle or *.xcodeproj
+			j := i
+			for j < len(rs) && isWordRune(rs[j]) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, token{tokIdent, string(rs[i:j])})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-./*?", r)
+}
+
+// --- Parser ---
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is one of the given operators/keywords
+func (p *parser) accept(words ...string) (string, bool) {
+	if p.done() {
+		return "", false
+	}
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, w := range words {
+		if t.text == w {
+			p.pos++
+			return w, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", ">", ">=", "<", "<=", "contains")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op, left, right}, nil
+}
+
+func (p *parser) parseValue() (node, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", t.text)
+		}
+		return literal{n}, nil
+
+	case tokString:
+		return literal{t.text}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "project", "cwd":
+			if _, ok := p.accept("has"); ok {
+				if p.done() || (p.peek().kind != tokIdent && p.peek().kind != tokString) {
+					return nil, fmt.Errorf("%s has: expected a path", t.text)
+				}
+				path := p.tokens[p.pos].text
+				p.pos++
+				return hasNode{base: t.text, path: path}, nil
+			}
+		}
+		return ident{t.text}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// --- Evaluation ---
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literal struct{ value any }
+
+func (n literal) eval(Env) (any, error) { return n.value, nil }
+
+type ident struct{ name string }
+
+func (n ident) eval(env Env) (any, error) {
+	v, ok := env.Vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown identifier %q", n.name)
+	}
+	return v, nil
+}
+
+type notNode struct{ inner node }
+
+func (n notNode) eval(env Env) (any, error) {
+	v, err := n.inner.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(env Env) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil || !truthy(l) {
+		return false, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(r), nil
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(env Env) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(l) {
+		return true, nil
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(r), nil
+}
+
+type hasNode struct {
+	base string // "project" or "cwd"
+	path string
+}
+
+func (n hasNode) eval(env Env) (any, error) {
+	dir := env.ProjectDir
+	if n.base == "cwd" {
+		dir = env.CurrentDir
+	}
+	if dir == "" {
+		return false, nil
+	}
+	pattern := filepath.Join(dir, filepath.FromSlash(n.path))
+	if strings.ContainsAny(n.path, "*?[") {
+		matches, err := filepath.Glob(pattern)
+		return err == nil && len(matches) > 0, nil
+	}
+	_, err := os.Stat(pattern)
+	return err == nil, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(env Env) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "contains" {
+		return strings.Contains(toString(l), toString(r)), nil
+	}
+
+	ln, lok := toNumber(l)
+	rn, rok := toNumber(r)
+	if lok && rok {
+		switch n.op {
+		case "==":
+			return ln == rn, nil
+		case "!=":
+			return ln != rn, nil
+		case ">":
+			return ln > rn, nil
+		case ">=":
+			return ln >= rn, nil
+		case "<":
+			return ln < rn, nil
+		case "<=":
+			return ln <= rn, nil
+		}
+	}
+
+	switch n.op {
+	case "==":
+		return toString(l) == toString(r), nil
+	case "!=":
+		return toString(l) != toString(r), nil
+	}
+	return nil, fmt.Errorf("%s needs numbers, got %v and %v", n.op, l, r)
+}
+
+func truthy(v any) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case string:
+		return x != ""
+	case nil:
+		return false
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+func toNumber(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,106 @@
+package expr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := Env{Vars: map[string]any{
+		"context_pct": 56,
+		"cost_usd":    1.25,
+		"model":       "Opus 4.5",
+		"is_idle":     false,
+		"is_git_repo": true,
+	}}
+
+	tests := []struct {
+		src      string
+		expected bool
+	}{
+		{"context_pct > 50", true},
+		{"context_pct >= 57", false},
+		{"cost_usd < 2 and is_git_repo", true},
+		{"is_idle or context_pct == 56", true},
+		{"not is_git_repo", false},
+		{"!is_idle && is_git_repo", true},
+		{`model contains "Opus"`, true},
+		{"model == 'Sonnet 4.5'", false},
+		{"model != 'Sonnet 4.5'", true},
+		{"not (is_idle or context_pct < 10)", true},
+		{"is_git_repo and (cost_usd > 5 or context_pct > 50)", true},
+		{"true", true},
+		{"model", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Eval(tt.src, env)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", tt.src, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.expected)
+		}
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	env := Env{Vars: map[string]any{"model": "Opus"}}
+
+	for _, src := range []string{
+		"",
+		"context_pct > 50", // Unknown identifier
+		"model >",          // Missing operand
+		"(model == 'Opus'", // Missing paren
+		"model == 'Opus",   // Unterminated string
+		"model = 'Opus'",   // Unknown operator
+		"model > 'Sonnet'", // Ordering needs numbers
+		"model == 'a' 'b'", // Trailing tokens
+		"project has",      // Missing path
+	} {
+		if _, err := Eval(src, env); err == nil {
+			t.Errorf("Eval(%q) expected an error", src)
+		}
+	}
+}
+
+func TestEval_ProjectHas(t *testing.T) {
+	dir, err := os.MkdirTemp("", "prism-test-expr-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.WriteFile(filepath.Join(dir, "build.gradle"), nil, 0644)
+	os.MkdirAll(filepath.Join(dir, "app", "src"), 0755)
+	os.MkdirAll(filepath.Join(dir, "Demo.xcodeproj"), 0755)
+
+	env := Env{ProjectDir: dir, CurrentDir: filepath.Join(dir, "app")}
+
+	tests := []struct {
+		src      string
+		expected bool
+	}{
+		{"project has build.gradle", true},
+		{"project has package.json", false},
+		{"project has app/src", true},
+		{"project has *.xcodeproj", true},
+		{`project has "build.gradle"`, true},
+		{"cwd has src", true},
+		{"cwd has build.gradle", false},
+		{"project has build.gradle and not project has pubspec.yaml", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Eval(tt.src, env)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", tt.src, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.expected)
+		}
+	}
+}
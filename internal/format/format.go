@@ -0,0 +1,69 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Get returns the "format" template configured for a section, if any.
+// cfg is the plugin config map passed to plugins (keyed by section name).
+func Get(cfg map[string]any, section string) string {
+	if sectionCfg, ok := cfg[section].(map[string]any); ok {
+		if f, ok := sectionCfg["format"].(string); ok {
+			return f
+		}
+	}
+	return ""
+}
+
+// Render executes a section format template with the given fields.
+//
+// Fields are accessed as {{.branch}}, {{.pct}}, etc. Color helpers use the
+// supplied palette (normally plugin.Input.Colors):
+//
+//	{{color "yellow"}}        start a color
+//	{{reset}}                 reset all styles
+//	{{wrap "cyan" .branch}}   color a single value
+//	{{truncate 20 .branch}}   shorten to n characters with "…"
+//
+// Unknown fields are an error so typos fall back to the default output
+// instead of printing "<no value>".
+func Render(tmpl string, fields map[string]any, palette map[string]string) (string, error) {
+	funcs := template.FuncMap{
+		"color": func(name string) string {
+			return palette[name]
+		},
+		"reset": func() string {
+			return palette["reset"]
+		},
+		"wrap": func(name string, value any) string {
+			return palette[name] + fmt.Sprint(value) + palette["reset"]
+		},
+		"truncate": truncate,
+	}
+
+	t, err := template.New("format").Funcs(funcs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := t.Execute(&out, fields); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func truncate(n int, value any) string {
+	s := fmt.Sprint(value) // As {{.field}} prints it
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	if n == 1 {
+		return "…"
+	}
+	return string(runes[:n-1]) + "…"
+}
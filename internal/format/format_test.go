@@ -0,0 +1,69 @@
+package format
+
+import "testing"
+
+var testPalette = map[string]string{
+	"yellow": "<y>",
+	"cyan":   "<c>",
+	"reset":  "<r>",
+}
+
+func TestGet(t *testing.T) {
+	cfg := map[string]any{
+		"git":   map[string]any{"format": "{{.branch}}"},
+		"other": "not a map",
+	}
+
+	if got := Get(cfg, "git"); got != "{{.branch}}" {
+		t.Errorf("expected git format, got %q", got)
+	}
+	if got := Get(cfg, "other"); got != "" {
+		t.Errorf("expected empty format for non-map config, got %q", got)
+	}
+	if got := Get(cfg, "missing"); got != "" {
+		t.Errorf("expected empty format for missing section, got %q", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	fields := map[string]any{
+		"branch": "feature/very-long-branch-name",
+		"dirty":  "*",
+		"ahead":  2,
+		"behind": 0,
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+	}{
+		{"plain fields", "{{.branch}}{{.dirty}}", "feature/very-long-branch-name*"},
+		{"color helpers", "{{color \"yellow\"}}{{.dirty}}{{reset}}", "<y>*<r>"},
+		{"wrap", "{{wrap \"cyan\" .ahead}}", "<c>2<r>"},
+		{"conditionals", "{{if .behind}}⇣{{.behind}}{{end}}{{if .ahead}}⇡{{.ahead}}{{end}}", "⇡2"},
+		{"truncate", "{{truncate 10 .branch}}", "feature/v…"},
+		{"unknown color is empty", "{{color \"nope\"}}x", "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.tmpl, fields, testPalette)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	if _, err := Render("{{.branch", nil, testPalette); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err := Render("{{.typo}}", map[string]any{"branch": "main"}, testPalette); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
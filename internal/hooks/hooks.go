@@ -2,13 +2,19 @@ package hooks
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/himattm/prism/internal/colors"
 	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/daemon"
+	"github.com/himattm/prism/internal/logging"
+	"github.com/himattm/prism/internal/plugin"
 	"github.com/himattm/prism/internal/plugins"
 )
 
@@ -17,15 +23,20 @@ type Input struct {
 	SessionID string `json:"session_id"`
 }
 
+// externalHookTimeout bounds a script or binary plugin's hook run
+const externalHookTimeout = 3 * time.Second
+
 // Manager handles hook execution
 type Manager struct {
 	registry *plugins.Registry
+	external *plugin.Manager // Script, binary and persistent plugins
 }
 
 // NewManager creates a new hook manager
 func NewManager() *Manager {
 	return &Manager{
 		registry: plugins.NewRegistry(),
+		external: plugin.NewManager(),
 	}
 }
 
@@ -55,12 +66,10 @@ func (m *Manager) HandleIdle(input Input) error {
 		Config:    pluginConfig,
 	}
 
-	outputs := m.registry.RunHooks(ctx, plugins.HookIdle, hookCtx)
+	outputs := m.runHooks(ctx, plugins.HookIdle, hookCtx)
 
 	// 4. Print any outputs (for Claude Code to display)
-	if len(outputs) > 0 {
-		fmt.Print(strings.Join(outputs, "\n"))
-	}
+	printOutputs(outputs)
 
 	return nil
 }
@@ -89,12 +98,10 @@ func (m *Manager) HandleBusy(input Input) error {
 		Config:    pluginConfig,
 	}
 
-	outputs := m.registry.RunHooks(ctx, plugins.HookBusy, hookCtx)
+	outputs := m.runHooks(ctx, plugins.HookBusy, hookCtx)
 
 	// 4. Print any outputs (for notifications)
-	if len(outputs) > 0 {
-		fmt.Print(strings.Join(outputs, "\n"))
-	}
+	printOutputs(outputs)
 
 	return nil
 }
@@ -108,11 +115,9 @@ func (m *Manager) HandleSessionStart(input Input) error {
 		SessionID: input.SessionID,
 	}
 
-	outputs := m.registry.RunHooks(ctx, plugins.HookSessionStart, hookCtx)
+	outputs := m.runHooks(ctx, plugins.HookSessionStart, hookCtx)
 
-	if len(outputs) > 0 {
-		fmt.Print(strings.Join(outputs, "\n"))
-	}
+	printOutputs(outputs)
 
 	return nil
 }
@@ -132,11 +137,9 @@ func (m *Manager) HandleSessionEnd(input Input) error {
 		SessionID: input.SessionID,
 	}
 
-	outputs := m.registry.RunHooks(ctx, plugins.HookSessionEnd, hookCtx)
+	outputs := m.runHooks(ctx, plugins.HookSessionEnd, hookCtx)
 
-	if len(outputs) > 0 {
-		fmt.Print(strings.Join(outputs, "\n"))
-	}
+	printOutputs(outputs)
 
 	return nil
 }
@@ -150,11 +153,98 @@ func (m *Manager) HandlePreCompact(input Input) error {
 		SessionID: input.SessionID,
 	}
 
-	outputs := m.registry.RunHooks(ctx, plugins.HookPreCompact, hookCtx)
+	outputs := m.runHooks(ctx, plugins.HookPreCompact, hookCtx)
 
-	if len(outputs) > 0 {
-		fmt.Print(strings.Join(outputs, "\n"))
-	}
+	printOutputs(outputs)
 
 	return nil
 }
+
+// runHooks runs the hook on native plugins, then on script and binary
+// plugins subscribed to it, then on the persistent plugins running in the
+// daemon. Without a daemon, subscribed persistent plugins are started just
+// for this hook.
+func (m *Manager) runHooks(ctx context.Context, hookType plugins.HookType, hookCtx plugins.HookContext) []string {
+	outputs := m.registry.RunHooks(ctx, hookType, hookCtx)
+
+	data, _ := json.Marshal(Input{SessionID: hookCtx.SessionID})
+	daemonOutput, daemonErr := daemon.Hook(string(hookType), data)
+
+	cfg := config.Load("")
+	discovered, err := m.external.Discover()
+	if err != nil {
+		logging.Warn("hook.plugin", "hook", string(hookType), "error", err)
+	}
+	var targets []plugin.Plugin
+	for _, p := range discovered {
+		if !p.Subscribes(string(hookType)) {
+			continue
+		}
+		if err := m.external.Runnable(p, cfg.RequireVerifiedPlugins()); err != nil {
+			logging.Debug("hook.plugin", "hook", string(hookType), "plugin", p.Name, "skipped", err)
+			continue
+		}
+		// The daemon already delivered to persistent plugins
+		if p.Metadata.Protocol == plugin.ProtocolPersistent && daemonErr == nil {
+			continue
+		}
+		targets = append(targets, p)
+	}
+	outputs = append(outputs, m.runExternalHooks(ctx, cfg, targets, hookType, hookCtx)...)
+	m.external.Close()
+
+	if daemonErr == nil && daemonOutput != "" {
+		outputs = append(outputs, daemonOutput)
+	}
+	return outputs
+}
+
+// runExternalHooks delivers the hook to plugins in parallel and returns
+// their outputs in the order given
+func (m *Manager) runExternalHooks(ctx context.Context, cfg config.Config, targets []plugin.Plugin, hookType plugins.HookType, hookCtx plugins.HookContext) []string {
+	timeout := externalHookTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	results := make([]string, len(targets))
+	var wg sync.WaitGroup
+	for i, p := range targets {
+		wg.Add(1)
+		go func(i int, p plugin.Plugin) {
+			defer wg.Done()
+			params := plugin.HookParams{
+				Type:      string(hookType),
+				SessionID: hookCtx.SessionID,
+				Config:    map[string]any{p.Name: p.Metadata.ApplyDefaults(cfg.LoadPluginConfig(p.Name))},
+			}
+			output, err := m.external.RunHook(p, params, p.Metadata.RunTimeout(timeout))
+			if err != nil {
+				logging.Warn("hook.plugin", "hook", string(hookType), "plugin", p.Name, "error", err)
+				return
+			}
+			if output != "" {
+				logging.Info("hook.plugin", "hook", string(hookType), "plugin", p.Name, "output", output)
+				results[i] = output
+			}
+		}(i, p)
+	}
+	wg.Wait()
+
+	var outputs []string
+	for _, output := range results {
+		if output != "" {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
+// printOutputs prints hook outputs with colors adjusted to the terminal
+func printOutputs(outputs []string) {
+	if len(outputs) == 0 {
+		return
+	}
+	level := colors.DetectLevel(config.Load("").ColorMode)
+	fmt.Print(colors.Downgrade(strings.Join(outputs, "\n"), level))
+}
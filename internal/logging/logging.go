@@ -0,0 +1,194 @@
+// Package logging writes Prism's optional debug log: one JSON object per
+// line in the user's state directory, rotated by size. Nothing is written
+// until a level is set (see the log_level config key).
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Level is the severity of an entry
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelOff
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "off"}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name such as "info"
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LevelOff, fmt.Errorf("unknown log level %q (use %v)", name, levelNames)
+}
+
+const (
+	// maxSize is the size at which the log is rotated
+	maxSize = 1 << 20
+	// keep is how many rotated files (prism.log.1, prism.log.2, ...) are kept
+	keep = 2
+)
+
+var (
+	mu    sync.Mutex
+	level = LevelOff
+)
+
+// SetLevel sets the minimum level written (LevelOff disables the log)
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// Configure sets the level from a log_level config value; an empty or
+// unknown value disables the log
+func Configure(name string) {
+	l, err := ParseLevel(name)
+	if err != nil {
+		l = LevelOff
+	}
+	SetLevel(l)
+}
+
+// Enabled reports whether entries at level l are written
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l >= level && level != LevelOff
+}
+
+// Dir returns the state directory holding the log:
+// $XDG_STATE_HOME/prism, or ~/.local/state/prism
+func Dir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "prism")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("prism-state-%d", os.Getuid()))
+	}
+	return filepath.Join(home, ".local", "state", "prism")
+}
+
+// Path returns the current log file
+func Path() string {
+	return filepath.Join(Dir(), "prism.log")
+}
+
+// Files returns the log files from oldest to newest
+func Files() []string {
+	var files []string
+	for i := keep; i >= 1; i-- {
+		files = append(files, fmt.Sprintf("%s.%d", Path(), i))
+	}
+	return append(files, Path())
+}
+
+// Debug logs an event with key/value fields, e.g.
+// Debug("plugin", "name", "git", "duration", elapsed). Errors and durations
+// are written as strings.
+func Debug(event string, fields ...any) { write(LevelDebug, event, fields) }
+
+// Info logs an event with key/value fields
+func Info(event string, fields ...any) { write(LevelInfo, event, fields) }
+
+// Warn logs an event with key/value fields
+func Warn(event string, fields ...any) { write(LevelWarn, event, fields) }
+
+// Error logs an event with key/value fields
+func Error(event string, fields ...any) { write(LevelError, event, fields) }
+
+func write(l Level, event string, fields []any) {
+	if !Enabled(l) {
+		return
+	}
+
+	entry := map[string]any{
+		"time":  time.Now().Format(time.RFC3339Nano),
+		"level": l.String(),
+		"event": event,
+		"pid":   os.Getpid(),
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, ok := fields[i].(string)
+		if !ok {
+			continue
+		}
+		value := fields[i+1]
+		switch v := value.(type) {
+		case error:
+			value = v.Error()
+		case time.Duration:
+			value = v.Round(10 * time.Microsecond).String()
+		}
+		entry[key] = value
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	// Logging must never break the status line, so errors are ignored
+	mu.Lock()
+	defer mu.Unlock()
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxSize {
+		rotate(path, int64(len(line)))
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(line)
+}
+
+// rotate shifts prism.log to prism.log.1, prism.log.1 to prism.log.2 and so
+// on, dropping the oldest. Other prism processes write the same log, so it
+// holds a file lock and checks the size again: another process may have
+// rotated it already.
+func rotate(path string, pending int64) {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	if info, err := os.Stat(path); err != nil || info.Size()+pending <= maxSize {
+		return
+	}
+	for i := keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
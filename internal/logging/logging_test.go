@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T, l Level) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	SetLevel(l)
+	t.Cleanup(func() { SetLevel(LevelOff) })
+}
+
+func TestLevels(t *testing.T) {
+	setup(t, LevelInfo)
+
+	Debug("hidden")
+	Info("hook", "hook", "idle", "session", "abc")
+	Error("update.auto", "action", "copy binary", "error", errors.New("permission denied"), "took", 1500*time.Microsecond)
+
+	entries := Read(Filter{})
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[1]
+	if e.Level != LevelError || e.Event != "update.auto" || e.PID != os.Getpid() {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.Fields["error"] != "permission denied" || e.Fields["took"] != "1.5ms" {
+		t.Errorf("unexpected fields %v", e.Fields)
+	}
+	if got := e.String(); !strings.HasSuffix(got, `ERROR update.auto action="copy binary" error="permission denied" took=1.5ms`) {
+		t.Errorf("unexpected format %q", got)
+	}
+
+	SetLevel(LevelOff)
+	Error("dropped")
+	if len(Read(Filter{})) != 2 {
+		t.Error("expected nothing written when off")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	setup(t, LevelDebug)
+	Debug("plugin", "plugin", "git")
+	Warn("plugin.timeout", "plugin", "android_devices")
+	Info("hook", "hook", "busy")
+
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{Level: LevelWarn}, []string{"plugin.timeout"}},
+		{Filter{Event: "plugin"}, []string{"plugin", "plugin.timeout"}},
+		{Filter{Text: "busy"}, []string{"hook"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range Read(tt.filter) {
+			got = append(got, e.Event)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%+v: expected %v, got %v", tt.filter, tt.want, got)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	setup(t, LevelInfo)
+	padding := strings.Repeat("x", 1000)
+	for i := 0; i < 3*maxSize/1000; i++ {
+		Info("fill", "i", i, "padding", padding)
+	}
+
+	for _, path := range Files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", path, err)
+		}
+		if info.Size() > maxSize {
+			t.Errorf("%s is %d bytes, over the limit", path, info.Size())
+		}
+	}
+	if _, err := os.Stat(Path() + ".3"); err == nil {
+		t.Error("expected only two rotated files to be kept")
+	}
+
+	// Entries stay in order across files
+	entries := Read(Filter{})
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Fields["i"].(float64) <= entries[i-1].Fields["i"].(float64) {
+			t.Fatalf("entries out of order at %d", i)
+		}
+	}
+}
+
+func TestRotate_SkipsWhenAlreadyRotated(t *testing.T) {
+	setup(t, LevelInfo)
+	path := Path()
+	os.MkdirAll(Dir(), 0700)
+	os.WriteFile(path, []byte(strings.Repeat("x", maxSize)), 0600)
+
+	rotate(path, 100)
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected %s.1 after rotating: %v", path, err)
+	}
+
+	// Another process saw the full log too, but rotates after the first
+	os.WriteFile(path, []byte("fresh\n"), 0600)
+	rotate(path, 100)
+	if _, err := os.Stat(path + ".2"); err == nil {
+		t.Error("rotated a log that another process had already rotated")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "fresh\n" {
+		t.Errorf("log = %q, want it left alone", data)
+	}
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one parsed log line
+type Entry struct {
+	Time   time.Time
+	Level  Level
+	Event  string
+	PID    int
+	Fields map[string]any // Everything else, e.g. "plugin" or "error"
+	Raw    string         // The JSON line as written
+}
+
+// ParseEntry parses a log line
+func ParseEntry(line string) (Entry, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{Raw: line, Fields: fields}
+	if s, ok := fields["time"].(string); ok {
+		e.Time, _ = time.Parse(time.RFC3339Nano, s)
+	}
+	if s, ok := fields["level"].(string); ok {
+		e.Level, _ = ParseLevel(s)
+	}
+	e.Event, _ = fields["event"].(string)
+	if pid, ok := fields["pid"].(float64); ok {
+		e.PID = int(pid)
+	}
+	for _, key := range []string{"time", "level", "event", "pid"} {
+		delete(fields, key)
+	}
+	return e, nil
+}
+
+// String formats the entry for reading, e.g.
+// "2026-01-02 15:04:05.000 INFO  hook type=idle session=abc"
+func (e Entry) String() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s", e.Time.Local().Format("2006-01-02 15:04:05.000"), strings.ToUpper(e.Level.String()), e.Event)
+	for _, k := range keys {
+		value := fmt.Sprint(e.Fields[k])
+		if strings.ContainsAny(value, " \t\"") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, value)
+	}
+	return b.String()
+}
+
+// Filter selects entries for `prism logs`
+type Filter struct {
+	Level Level  // Minimum level
+	Event string // Event name prefix, e.g. "hook" or "plugin"
+	Text  string // Substring of the raw line
+}
+
+// Match reports whether e passes the filter
+func (f Filter) Match(e Entry) bool {
+	if e.Level < f.Level {
+		return false
+	}
+	if f.Event != "" && !strings.HasPrefix(e.Event, f.Event) {
+		return false
+	}
+	return f.Text == "" || strings.Contains(e.Raw, f.Text)
+}
+
+// Read returns the entries matching f from all log files, oldest first.
+// Lines that do not parse are skipped.
+func Read(f Filter) []Entry {
+	var entries []Entry
+	for _, path := range Files() {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, scan(bufio.NewScanner(file), f)...)
+		file.Close()
+	}
+	return entries
+}
+
+func scan(scanner *bufio.Scanner, f Filter) []Entry {
+	var entries []Entry
+	scanner.Buffer(make([]byte, 64*1024), maxSize)
+	for scanner.Scan() {
+		e, err := ParseEntry(scanner.Text())
+		if err == nil && f.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HookTypes are the hook events a plugin can subscribe to with @hooks
+var HookTypes = []string{"idle", "busy", "session_start", "session_end", "pre_compact"}
+
+// Subscribes reports whether the plugin wants hookType events. Plugins list
+// them with @hooks (or "hooks" in a binary's sidecar); a persistent plugin
+// that lists none gets every event.
+func (p Plugin) Subscribes(hookType string) bool {
+	if len(p.Metadata.Hooks) == 0 {
+		return p.Metadata.Protocol == ProtocolPersistent
+	}
+	for _, h := range p.Metadata.Hooks {
+		if h == hookType {
+			return true
+		}
+	}
+	return false
+}
+
+// RunHook delivers a hook event to a plugin and returns its notification
+// text, if any. A persistent plugin gets a "hook" request; any other plugin
+// is run as `<plugin> hook <type>` with params as JSON on stdin.
+func (m *Manager) RunHook(p Plugin, params HookParams, timeout time.Duration) (string, error) {
+	if err := m.CheckIntegrity(p); err != nil {
+		return "", err
+	}
+	if p.Metadata.Protocol == ProtocolPersistent {
+		return m.Hook(p, params, timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal hook params: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.Path, "hook", params.Type)
+	cmd.Stdin = bytes.NewReader(paramsJSON)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("plugin timed out")
+		}
+		return "", fmt.Errorf("plugin error: %w (stderr: %s)", err, stderr.String())
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// parseHooks splits an @hooks value such as "idle, pre_compact"
+func parseHooks(value string) []string {
+	var hooks []string
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
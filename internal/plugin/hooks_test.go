@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const hookScript = `#!/bin/sh
+# @name builds
+# @hooks idle, pre_compact
+if [ "$1" = "hook" ]; then
+  params=$(cat)
+  case "$params" in
+    *'"session_id":"abc"'*) echo "$2 for abc" ;;
+  esac
+  exit 0
+fi
+echo "render"
+`
+
+func TestRunHook_Script(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prism-plugin-builds.sh")
+	if err := os.WriteFile(path, []byte(hookScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{pluginDir: dir}
+
+	plugins, err := m.Discover()
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("Discover = %v, %v", plugins, err)
+	}
+	p := plugins[0]
+
+	if got := p.Metadata.Hooks; len(got) != 2 || got[0] != "idle" || got[1] != "pre_compact" {
+		t.Errorf("hooks = %q, want [idle pre_compact]", got)
+	}
+
+	output, err := m.RunHook(p, HookParams{Type: "idle", SessionID: "abc"}, 2*time.Second)
+	if err != nil || output != "idle for abc" {
+		t.Errorf("RunHook = %q, %v", output, err)
+	}
+
+	// A render still runs without arguments
+	if output, err := m.Execute(p, Input{}, 2*time.Second); err != nil || output != "render" {
+		t.Errorf("Execute = %q, %v", output, err)
+	}
+}
+
+func TestRunHook_Failure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prism-plugin-bad.sh")
+	os.WriteFile(path, []byte("#!/bin/sh\necho oops >&2\nexit 1\n"), 0755)
+
+	_, err := NewManager().RunHook(Plugin{Name: "bad", Path: path}, HookParams{Type: "idle"}, 2*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("RunHook error = %v, want stderr in the error", err)
+	}
+}
+
+func TestSubscribes(t *testing.T) {
+	tests := []struct {
+		name string
+		meta Metadata
+		hook string
+		want bool
+	}{
+		{"listed", Metadata{Hooks: []string{"idle"}}, "idle", true},
+		{"not listed", Metadata{Hooks: []string{"idle"}}, "busy", false},
+		{"script without hooks", Metadata{}, "idle", false},
+		{"persistent without hooks", Metadata{Protocol: ProtocolPersistent}, "busy", true},
+		{"persistent narrowed", Metadata{Protocol: ProtocolPersistent, Hooks: []string{"idle"}}, "busy", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Plugin{Metadata: tt.meta}).Subscribes(tt.hook); got != tt.want {
+				t.Errorf("Subscribes(%q) = %v, want %v", tt.hook, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// LockFile pins a project's plugin set. It lives in the project's .claude
+// directory and is meant to be committed, so everyone who clones the
+// project can install the same plugins with 'prism plugin sync'.
+const LockFile = "prism-plugins.lock"
+
+// anyPlatform is the digest key for scripts, which are the same everywhere
+const anyPlatform = "any"
+
+// Lock is the content of a lockfile
+type Lock struct {
+	Plugins []LockedPlugin `json:"plugins"`
+}
+
+// LockedPlugin is one pinned plugin
+type LockedPlugin struct {
+	Name    string            `json:"name"`
+	Source  string            `json:"source"`            // URL it is installed from
+	Version string            `json:"version,omitempty"` // Release or script version; empty for whatever the URL serves
+	Binary  bool              `json:"binary,omitempty"`
+	SHA256  map[string]string `json:"sha256"` // Digest per platform, e.g. "darwin-arm64", or "any" for scripts
+}
+
+// LockDiff is how the installed plugins differ from a lockfile
+type LockDiff struct {
+	Install []LockedPlugin // Missing, or a different source, version or digest
+	Remove  []Plugin       // Installed from a URL, but not locked; sync --prune removes them
+	Local   []Plugin       // Not locked, but not installed from a URL either; sync keeps them
+	Current []string       // Installed as locked
+}
+
+// InSync reports whether every locked plugin is installed as locked.
+// Other plugins may be there too (see Remove and Local).
+func (d LockDiff) InSync() bool {
+	return len(d.Install) == 0
+}
+
+// LockPath returns the lockfile path for projectDir
+func LockPath(projectDir string) string {
+	return filepath.Join(projectDir, ".claude", LockFile)
+}
+
+// ReadLock reads a lockfile. Each plugin's name must be the one its source
+// installs as, or sync would install it under another name.
+func ReadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	for i, lp := range lock.Plugins {
+		if lp.Name == "" || lp.Source == "" {
+			return nil, fmt.Errorf("invalid %s: plugin %d needs a name and a source", path, i+1)
+		}
+		if name := sourceName(lp.Source); name != lp.Name {
+			return nil, fmt.Errorf("invalid %s: plugin %q is installed from %s as %q", path, lp.Name, lp.Source, name)
+		}
+	}
+	return &lock, nil
+}
+
+// Write saves the lockfile, sorted by plugin name so it diffs cleanly
+func (l *Lock) Write(path string) error {
+	sort.Slice(l.Plugins, func(i, j int) bool { return l.Plugins[i].Name < l.Plugins[j].Name })
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// find returns the locked plugin called name
+func (l *Lock) find(name string) *LockedPlugin {
+	for i := range l.Plugins {
+		if l.Plugins[i].Name == name {
+			return &l.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// platform is the digest key of a plugin on this machine
+func platform(binary bool) string {
+	if binary {
+		return runtime.GOOS + "-" + runtime.GOARCH
+	}
+	return anyPlatform
+}
+
+// digest returns the pinned digest for this machine, if the lockfile has one
+func (lp LockedPlugin) digest() string {
+	return lp.SHA256[platform(lp.Binary)]
+}
+
+// Freeze writes a lockfile pinning the installed plugins. Digests the
+// existing lockfile holds for other platforms are kept while a plugin's
+// source and version are unchanged, so a team on mixed machines can each
+// freeze to add theirs. Locked plugins not installed here stay locked
+// unless prune is set. Plugins not installed from a URL are skipped.
+func (m *Manager) Freeze(path string, prune bool) error {
+	plugins, err := m.Discover()
+	if err != nil {
+		return err
+	}
+	previous, err := ReadLock(path)
+	if errors.Is(err, os.ErrNotExist) {
+		previous, err = &Lock{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lock := &Lock{Plugins: []LockedPlugin{}}
+	installed := make(map[string]bool, len(plugins))
+	for _, p := range plugins {
+		installed[p.Name] = true
+		if p.Metadata.Source == "" {
+			fmt.Printf("  %s: skipped (not installed from a URL; use 'prism plugin add' to lock it)\n", p.Name)
+			continue
+		}
+		if err := m.CheckIntegrity(p); err != nil {
+			return err
+		}
+		digest, err := m.fileDigest(p.Path) // Cached by CheckIntegrity
+		if err != nil {
+			return err
+		}
+
+		lp := LockedPlugin{
+			Name:    p.Name,
+			Source:  p.Metadata.Source,
+			Version: p.Metadata.Version,
+			Binary:  p.IsBinary,
+			SHA256:  map[string]string{},
+		}
+		if old := previous.find(p.Name); old != nil && old.Source == lp.Source && old.Version == lp.Version && old.Binary == lp.Binary {
+			for key, digest := range old.SHA256 {
+				lp.SHA256[key] = digest
+			}
+		}
+		lp.SHA256[platform(p.IsBinary)] = digest
+		lock.Plugins = append(lock.Plugins, lp)
+	}
+
+	for _, old := range previous.Plugins {
+		if installed[old.Name] {
+			continue
+		}
+		if prune {
+			fmt.Printf("  %s: unlocked (not installed here)\n", old.Name)
+			continue
+		}
+		fmt.Printf("  %s: kept (not installed here; 'prism plugin freeze --prune' unlocks it)\n", old.Name)
+		lock.Plugins = append(lock.Plugins, old)
+	}
+
+	if err := lock.Write(path); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	fmt.Printf("Locked %d plugin(s) in %s\n", len(lock.Plugins), path)
+	return nil
+}
+
+// Diff compares the installed plugins with lock
+func (m *Manager) Diff(lock *Lock) (LockDiff, error) {
+	plugins, err := m.Discover()
+	if err != nil {
+		return LockDiff{}, err
+	}
+	installed := make(map[string]Plugin, len(plugins))
+	for _, p := range plugins {
+		installed[p.Name] = p
+	}
+
+	var diff LockDiff
+	for _, lp := range lock.Plugins {
+		if p, ok := installed[lp.Name]; ok && m.matchesLock(p, lp) {
+			diff.Current = append(diff.Current, lp.Name)
+		} else {
+			diff.Install = append(diff.Install, lp)
+		}
+	}
+	for _, p := range plugins {
+		if lock.find(p.Name) != nil {
+			continue
+		}
+		if p.Metadata.Source == "" {
+			diff.Local = append(diff.Local, p)
+		} else {
+			diff.Remove = append(diff.Remove, p)
+		}
+	}
+	return diff, nil
+}
+
+// matchesLock reports whether p is installed as lp pins it
+func (m *Manager) matchesLock(p Plugin, lp LockedPlugin) bool {
+	if p.IsBinary != lp.Binary || p.Metadata.Source != lp.Source {
+		return false
+	}
+	if lp.Version != "" && p.Metadata.Version != lp.Version {
+		return false
+	}
+	want := lp.digest()
+	if want == "" {
+		// Not frozen on this platform yet; the version is all there is to check
+		return m.CheckIntegrity(p) == nil
+	}
+	digest, err := m.fileDigest(p.Path)
+	return err == nil && strings.EqualFold(digest, want)
+}
+
+// Sync makes the installed plugins match lock: it installs locked plugins
+// that are missing or differ. Plugins installed from a URL that are not
+// locked may be used by other projects, so they are only removed with prune.
+// Plugins not installed from a URL are left alone. The plan is printed
+// before anything changes.
+func (m *Manager) Sync(lock *Lock, prune bool) error {
+	diff, err := m.Diff(lock)
+	if err != nil {
+		return err
+	}
+	installed, err := m.Discover()
+	if err != nil {
+		return err
+	}
+
+	for _, lp := range diff.Install {
+		fmt.Printf("  %s: install %s\n", lp.Name, lockedVersion(lp))
+	}
+	for _, p := range diff.Remove {
+		if prune {
+			fmt.Printf("  %s: remove (not in the lockfile)\n", p.Name)
+		} else {
+			fmt.Printf("  %s: kept (not in the lockfile; 'prism plugin sync --prune' removes it)\n", p.Name)
+		}
+	}
+	for _, p := range diff.Local {
+		fmt.Printf("  %s: kept (not in the lockfile, not installed from a URL)\n", p.Name)
+	}
+	for _, name := range diff.Current {
+		fmt.Printf("  %s: up to date\n", name)
+	}
+
+	var failed []string
+	for _, lp := range diff.Install {
+		// A plugin switching between script and binary lands at a new path
+		for _, p := range installed {
+			if p.Name == lp.Name && p.IsBinary != lp.Binary {
+				os.Remove(p.Path)
+				os.Remove(p.Path + ".json")
+			}
+		}
+		if lp.digest() == "" {
+			fmt.Printf("  %s: no digest for %s in the lockfile; run 'prism plugin freeze' to add it\n", lp.Name, platform(lp.Binary))
+		}
+		if err := m.installLocked(lp); err != nil {
+			fmt.Printf("  %s: %v\n", lp.Name, err)
+			failed = append(failed, lp.Name)
+		}
+	}
+	if prune {
+		for _, p := range diff.Remove {
+			if err := m.Remove(p.Name); err != nil {
+				fmt.Printf("  %s: %v\n", p.Name, err)
+				failed = append(failed, p.Name)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// lockedVersion describes the version a lockfile pins, for the sync plan
+func lockedVersion(lp LockedPlugin) string {
+	if lp.Version == "" {
+		return "from " + lp.Source
+	}
+	return "v" + lp.Version
+}
+
+// installLocked installs a locked plugin the way Add would have, pinned to
+// its version and digest
+func (m *Manager) installLocked(lp LockedPlugin) error {
+	opts := installOptions{version: lp.Version, sha256: lp.digest(), overwrite: true}
+	owner, repo, ok := githubRepo(lp.Source)
+	if !ok {
+		return m.addFromDirectURL(lp.Source, opts)
+	}
+	if lp.Binary {
+		return m.addBinaryPlugin(owner, repo, lp.Name, opts)
+	}
+	return m.addScriptPlugin(owner, repo, lp.Name, opts)
+}
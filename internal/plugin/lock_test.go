@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lockBinary = "\x7fELF not really a binary"
+
+// newLockServer serves a script and a binary plugin with checksums
+func newLockServer(t *testing.T) string {
+	t.Helper()
+	return newPluginServer(t, map[string]string{
+		"/prism-plugin-ci.sh":                   verifyScript,
+		"/prism-plugin-ci.sh.sha256":            Digest([]byte(verifyScript)) + "  prism-plugin-ci.sh\n",
+		"/prism-plugin-tool-linux-amd64":        lockBinary,
+		"/prism-plugin-tool-linux-amd64.sha256": Digest([]byte(lockBinary)) + "  prism-plugin-tool-linux-amd64\n",
+		"/prism-plugin-stale.sh":                verifyScript,
+		"/prism-plugin-stale.sh.sha256":         Digest([]byte(verifyScript)) + "  prism-plugin-stale.sh\n",
+	}).URL
+}
+
+func TestFreezeAndSync(t *testing.T) {
+	url := newLockServer(t)
+	lockPath := filepath.Join(t.TempDir(), ".claude", LockFile)
+
+	// One engineer installs plugins and freezes them
+	m := &Manager{pluginDir: t.TempDir()}
+	for _, u := range []string{url + "/prism-plugin-ci.sh", url + "/prism-plugin-tool-linux-amd64"} {
+		if err := m.Add(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(m.pluginDir, "prism-plugin-mine.sh"), []byte("#!/bin/sh\necho mine\n"), 0755)
+	if err := m.Freeze(lockPath, false); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := ReadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Plugins) != 2 || lock.Plugins[0].Name != "ci" || lock.Plugins[1].Name != "tool" {
+		t.Fatalf("locked %+v, want ci and tool (not the local plugin)", lock.Plugins)
+	}
+	ci, tool := lock.Plugins[0], lock.Plugins[1]
+	if ci.Binary || ci.Version != "1.0.0" || ci.SHA256[anyPlatform] != Digest([]byte(verifyScript)) {
+		t.Errorf("ci locked as %+v", ci)
+	}
+	if !tool.Binary || tool.digest() != Digest([]byte(lockBinary)) {
+		t.Errorf("tool locked as %+v", tool)
+	}
+
+	// Another engineer has a stale plugin and a local one, and syncs
+	other := &Manager{pluginDir: t.TempDir()}
+	if err := other.Add(url + "/prism-plugin-stale.sh"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(other.pluginDir, "prism-plugin-mine.sh"), []byte("#!/bin/sh\necho mine\n"), 0755)
+
+	diff, err := other.Diff(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.InSync() || len(diff.Install) != 2 || len(diff.Remove) != 1 || len(diff.Local) != 1 {
+		t.Fatalf("diff before sync = %+v", diff)
+	}
+
+	installedNames := func() string {
+		plugins, _ := other.Discover()
+		var names []string
+		for _, p := range plugins {
+			names = append(names, p.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// The stale plugin may be used elsewhere, so only --prune removes it
+	if err := other.Sync(lock, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := installedNames(); got != "ci,mine,stale,tool" {
+		t.Errorf("after sync installed %s, want ci, mine, stale and tool", got)
+	}
+	if err := other.Sync(lock, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := installedNames(); got != "ci,mine,tool" {
+		t.Errorf("after sync --prune installed %s, want ci, mine and tool", got)
+	}
+	if diff, _ := other.Diff(lock); !diff.InSync() || len(diff.Current) != 2 {
+		t.Errorf("diff after sync = %+v", diff)
+	}
+}
+
+func TestSync_RejectsDigestMismatch(t *testing.T) {
+	url := newLockServer(t)
+	m := &Manager{pluginDir: t.TempDir()}
+	lock := &Lock{Plugins: []LockedPlugin{{
+		Name:    "ci",
+		Source:  url + "/prism-plugin-ci.sh",
+		Version: "1.0.0",
+		SHA256:  map[string]string{anyPlatform: Digest([]byte("something else"))},
+	}}}
+
+	err := m.Sync(lock, false)
+	if err == nil || !strings.Contains(err.Error(), "ci") {
+		t.Fatalf("Sync error = %v, want ci to fail", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.pluginDir, "prism-plugin-ci.sh")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("plugin with the wrong digest was installed")
+	}
+
+	// The pinned digest is checked on its own, too
+	opts := installOptions{sha256: lock.Plugins[0].digest()}
+	if err := opts.checkPinned(Digest([]byte(verifyScript))); err == nil || !strings.Contains(err.Error(), "lockfile") {
+		t.Errorf("checkPinned = %v, want a lockfile mismatch", err)
+	}
+}
+
+func TestFreeze_KeepsOtherPlatforms(t *testing.T) {
+	url := newLockServer(t)
+	lockPath := filepath.Join(t.TempDir(), LockFile)
+	m := &Manager{pluginDir: t.TempDir()}
+	if err := m.Add(url + "/prism-plugin-tool-linux-amd64"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Frozen before on another platform, for the same source and version
+	previous := &Lock{Plugins: []LockedPlugin{{
+		Name:   "tool",
+		Source: url + "/prism-plugin-tool-linux-amd64",
+		Binary: true,
+		SHA256: map[string]string{"plan9-arm": "abc123"},
+	}}}
+	if err := previous.Write(lockPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Freeze(lockPath, false); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests := lock.Plugins[0].SHA256
+	if digests["plan9-arm"] != "abc123" || digests[platform(true)] != Digest([]byte(lockBinary)) {
+		t.Errorf("digests = %v, want both platforms", digests)
+	}
+}
+
+func TestFreeze_KeepsPluginsNotInstalled(t *testing.T) {
+	url := newLockServer(t)
+	lockPath := filepath.Join(t.TempDir(), LockFile)
+	m := &Manager{pluginDir: t.TempDir()}
+	if err := m.Add(url + "/prism-plugin-ci.sh"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A teammate locked a plugin this machine does not have
+	previous := &Lock{Plugins: []LockedPlugin{{
+		Name:   "stale",
+		Source: url + "/prism-plugin-stale.sh",
+		SHA256: map[string]string{anyPlatform: "abc123"},
+	}}}
+	if err := previous.Write(lockPath); err != nil {
+		t.Fatal(err)
+	}
+
+	lockedNames := func() string {
+		lock, err := ReadLock(lockPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, lp := range lock.Plugins {
+			names = append(names, lp.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if err := m.Freeze(lockPath, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := lockedNames(); got != "ci,stale" {
+		t.Errorf("after freeze locked %s, want ci and stale", got)
+	}
+	if err := m.Freeze(lockPath, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := lockedNames(); got != "ci" {
+		t.Errorf("after freeze --prune locked %s, want ci", got)
+	}
+}
+
+func TestReadLock_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+	os.WriteFile(path, []byte(`{"plugins": [{"name": "ci"}]}`), 0644)
+	if _, err := ReadLock(path); err == nil || !strings.Contains(err.Error(), "source") {
+		t.Errorf("ReadLock = %v, want a missing source error", err)
+	}
+	// sync would install it as "ci" and never see "tests" as current
+	os.WriteFile(path, []byte(`{"plugins": [{"name": "tests", "source": "https://github.com/acme/prism-plugin-ci"}]}`), 0644)
+	if _, err := ReadLock(path); err == nil || !strings.Contains(err.Error(), `as "ci"`) {
+		t.Errorf("ReadLock = %v, want a name mismatch error", err)
+	}
+}
+
+func TestScriptRefs(t *testing.T) {
+	if refs := scriptRefs(""); strings.Join(refs, ",") != "main" {
+		t.Errorf("unpinned refs = %v, want main", refs)
+	}
+	// main may hold any version, so a pinned one never falls back to it
+	if refs := scriptRefs("1.2.0"); strings.Join(refs, ",") != "v1.2.0,1.2.0" {
+		t.Errorf("pinned refs = %v, want only the version's tags", refs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,19 +16,31 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/himattm/prism/internal/cache"
 )
 
 // Manager handles plugin discovery, execution, and management
 type Manager struct {
 	pluginDir string
+
+	trust Trust // How downloads are verified (see SetTrust)
+
+	mu       sync.Mutex
+	running  map[string]*supervised // Persistent plugins, keyed by path
+	digests  *cache.Cache           // Plugin file digests (see CheckIntegrity)
+	commands *cache.Cache           // Required commands found on PATH (see Runnable)
 }
 
-// NewManager creates a new plugin manager
+// NewManager creates a new plugin manager. File digests are persisted, so a
+// one-shot render does not hash every plugin again.
 func NewManager() *Manager {
 	homeDir, _ := os.UserHomeDir()
 	return &Manager{
 		pluginDir: filepath.Join(homeDir, ".claude", "prism-plugins"),
+		digests:   cache.NewPersistent(cache.Path("digests")),
 	}
 }
 
@@ -68,6 +81,10 @@ func (m *Manager) Discover() ([]Plugin, error) {
 			// Binary plugin: try sidecar JSON first
 			meta = m.loadBinaryMetadata(path)
 			pluginName = strings.TrimPrefix(name, "prism-plugin-")
+		} else if sidecar := m.loadBinaryMetadata(path); sidecar.Name != "" {
+			// Script plugin installed with a manifest
+			meta = sidecar
+			pluginName = strings.TrimSuffix(strings.TrimPrefix(name, "prism-plugin-"), ".sh")
 		} else {
 			// Script plugin: parse header comments
 			var err error
@@ -102,7 +119,8 @@ func (m *Manager) Discover() ([]Plugin, error) {
 	return plugins, nil
 }
 
-// loadBinaryMetadata loads metadata from sidecar JSON file
+// loadBinaryMetadata loads metadata from sidecar JSON file (a binary's
+// metadata, or any plugin's installed manifest)
 func (m *Manager) loadBinaryMetadata(binaryPath string) Metadata {
 	jsonPath := binaryPath + ".json"
 	data, err := os.ReadFile(jsonPath)
@@ -164,6 +182,10 @@ func ParseMetadata(path string) (Metadata, error) {
 				meta.Source = value
 			case "update-url":
 				meta.UpdateURL = value
+			case "protocol":
+				meta.Protocol = value
+			case "hooks":
+				meta.Hooks = parseHooks(value)
 			}
 		}
 	}
@@ -171,8 +193,16 @@ func ParseMetadata(path string) (Metadata, error) {
 	return meta, scanner.Err()
 }
 
-// Execute runs a plugin and returns its output
+// Execute runs a plugin and returns its output. Persistent plugins are
+// asked over their running process instead of being started.
 func (m *Manager) Execute(p Plugin, input Input, timeout time.Duration) (string, error) {
+	if err := m.CheckIntegrity(p); err != nil {
+		return "", err
+	}
+	if p.Metadata.Protocol == ProtocolPersistent {
+		return m.executePersistent(p, input, timeout)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -264,77 +294,135 @@ func (m *Manager) List(nativePlugins []NativePluginInfo) {
 		fmt.Println("  (no plugins installed)")
 	}
 
+	// Plugins that may not run are hidden from the status line
+	var unavailable []string
+	for _, p := range communityPlugins {
+		if err := m.Runnable(p, m.trust.RequireVerified); err != nil {
+			unavailable = append(unavailable, fmt.Sprintf("  %-*s %s", nameWidth, p.Name, strings.TrimPrefix(err.Error(), "plugin "+p.Name+" ")))
+		}
+	}
+	if len(unavailable) > 0 {
+		fmt.Println()
+		fmt.Println("Unavailable here (hidden from the status line):")
+		for _, line := range unavailable {
+			fmt.Println(line)
+		}
+	}
+
 	fmt.Println()
 	fmt.Printf("Community plugins: %s\n", m.pluginDir)
 }
 
+// installOptions pin what the add paths install; the zero value installs
+// the latest version and asks before overwriting
+type installOptions struct {
+	version   string // Release or script version to install; empty for the latest
+	sha256    string // Digest the download must have; empty for any
+	overwrite bool   // Replace an installed copy without asking
+}
+
+// checkPinned rejects a download that does not have the pinned digest
+func (o installOptions) checkPinned(digest string) error {
+	if o.sha256 != "" && !strings.EqualFold(o.sha256, digest) {
+		return fmt.Errorf("sha256 %s does not match the lockfile (%s)", short(digest), short(o.sha256))
+	}
+	return nil
+}
+
+// githubRepo splits a https://github.com/OWNER/REPO URL
+func githubRepo(url string) (owner, repo string, ok bool) {
+	if !strings.HasPrefix(url, "https://github.com/") {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(url, "https://github.com/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// sourceName returns the name a plugin installed from url gets: the repo
+// name, or the file name for a direct URL, without "prism-plugin-"
+func sourceName(url string) string {
+	if _, repo, ok := githubRepo(url); ok {
+		return strings.TrimPrefix(repo, "prism-plugin-")
+	}
+	pluginName := strings.TrimPrefix(filepath.Base(url), "prism-plugin-")
+	pluginName = strings.TrimSuffix(pluginName, ".sh")
+	// Remove platform suffix if present
+	for _, suffix := range []string{"-darwin-arm64", "-darwin-amd64", "-linux-amd64", "-linux-arm64"} {
+		pluginName = strings.TrimSuffix(pluginName, suffix)
+	}
+	return pluginName
+}
+
 // Add installs a plugin from a URL (supports both binary and script plugins)
 func (m *Manager) Add(url string) error {
 	// Parse GitHub URL
-	if strings.HasPrefix(url, "https://github.com/") {
-		parts := strings.Split(strings.TrimPrefix(url, "https://github.com/"), "/")
-		if len(parts) >= 2 {
-			owner, repo := parts[0], parts[1]
-			pluginName := strings.TrimPrefix(repo, "prism-plugin-")
-
-			// Try binary release first
-			if err := m.addBinaryPlugin(owner, repo, pluginName); err == nil {
-				return nil
-			}
+	if owner, repo, ok := githubRepo(url); ok {
+		pluginName := sourceName(url)
 
-			// Fall back to script
-			fmt.Println("No binary release found, trying script...")
-			return m.addScriptPlugin(owner, repo, pluginName)
+		// Try binary release first
+		err := m.addBinaryPlugin(owner, repo, pluginName, installOptions{})
+		if err == nil {
+			return nil
+		}
+		// Refused, as opposed to no binary release to install
+		var incompatible *IncompatibleError
+		var unverified *VerifyError
+		if errors.As(err, &incompatible) || errors.As(err, &unverified) {
+			return err
 		}
+
+		// Fall back to script
+		fmt.Println("No binary release found, trying script...")
+		return m.addScriptPlugin(owner, repo, pluginName, installOptions{})
 	}
 
 	// Direct URL - try to download as-is
-	return m.addFromDirectURL(url)
+	return m.addFromDirectURL(url, installOptions{})
 }
 
 // addBinaryPlugin downloads a binary plugin from GitHub releases
-func (m *Manager) addBinaryPlugin(owner, repo, pluginName string) error {
+func (m *Manager) addBinaryPlugin(owner, repo, pluginName string, opts installOptions) error {
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
 
 	// Try to fetch release info
-	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
 	client := &http.Client{Timeout: 10 * time.Second}
-
-	req, err := http.NewRequest("GET", releaseURL, nil)
+	release, err := fetchRelease(client, owner, repo, opts.version)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("no releases found")
-	}
-
-	var release struct {
-		TagName string `json:"tag_name"`
-		Assets  []struct {
-			Name               string `json:"name"`
-			BrowserDownloadURL string `json:"browser_download_url"`
-		} `json:"assets"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return err
-	}
 
-	// Find binary for our platform
+	// Find binary for our platform, and the manifest if the release has one
 	binaryName := fmt.Sprintf("prism-plugin-%s-%s-%s", pluginName, osName, arch)
-	var downloadURL string
+	var downloadURL, manifestURL string
+	assets := make(map[string]string)
 	for _, asset := range release.Assets {
-		if asset.Name == binaryName {
+		assets[asset.Name] = asset.BrowserDownloadURL
+		switch asset.Name {
+		case binaryName:
 			downloadURL = asset.BrowserDownloadURL
-			break
+		case ManifestFile:
+			manifestURL = asset.BrowserDownloadURL
+		}
+	}
+
+	var manifest *Metadata
+	var manifestData []byte
+	if manifestURL != "" {
+		manifest, manifestData, err = fetchManifest(client, manifestURL)
+		if errors.Is(err, errNoManifest) {
+			manifest, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		if manifest != nil {
+			if err := manifest.Compatible(); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -345,7 +433,7 @@ func (m *Manager) addBinaryPlugin(owner, repo, pluginName string) error {
 	fmt.Printf("Downloading %s (%s-%s)...\n", pluginName, osName, arch)
 
 	// Download binary
-	resp, err = client.Get(downloadURL)
+	resp, err := client.Get(downloadURL)
 	if err != nil {
 		return err
 	}
@@ -360,6 +448,15 @@ func (m *Manager) addBinaryPlugin(owner, repo, pluginName string) error {
 		return err
 	}
 
+	source := fmt.Sprintf("https://github.com/%s/%s", owner, repo)
+	v, err := m.verifyRelease(client, assets, binaryName, source, content, manifestData)
+	if err == nil {
+		err = opts.checkPinned(v.sha256)
+	}
+	if err != nil {
+		return &VerifyError{Plugin: pluginName, Err: err}
+	}
+
 	// Install
 	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
 		return err
@@ -368,8 +465,10 @@ func (m *Manager) addBinaryPlugin(owner, repo, pluginName string) error {
 	destPath := filepath.Join(m.pluginDir, fmt.Sprintf("prism-plugin-%s", pluginName))
 
 	// Check if already installed
-	if err := m.checkExistingPlugin(destPath, pluginName); err != nil {
-		return err
+	if !opts.overwrite {
+		if err := m.checkExistingPlugin(destPath, pluginName); err != nil {
+			return err
+		}
 	}
 
 	if err := os.WriteFile(destPath, content, 0755); err != nil {
@@ -378,37 +477,55 @@ func (m *Manager) addBinaryPlugin(owner, repo, pluginName string) error {
 
 	// Save metadata
 	version := strings.TrimPrefix(release.TagName, "v")
-	meta := Metadata{
-		Name:      pluginName,
-		Version:   version,
-		Source:    fmt.Sprintf("https://github.com/%s/%s", owner, repo),
-		UpdateURL: fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo),
-	}
+	meta := Metadata{}
+	if manifest != nil {
+		meta = *manifest
+	}
+	meta.Name = pluginName
+	meta.Version = version
+	meta.Source = source
+	meta.UpdateURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
+	meta.SHA256, meta.Verified = v.sha256, v.verified
 	m.saveBinaryMetadata(destPath, meta)
 
-	fmt.Printf("Installed: %s v%s (binary)\n", pluginName, version)
+	fmt.Printf("Installed: %s v%s (binary, %s)\n", pluginName, version, describeVerified(meta))
+	warnMissingCommands(meta)
 	return nil
 }
 
 // addScriptPlugin downloads a script plugin from GitHub
-func (m *Manager) addScriptPlugin(owner, repo, pluginName string) error {
-	rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/main/prism-plugin-%s.sh", owner, repo, pluginName)
-
-	fmt.Printf("Fetching script from: %s\n", rawURL)
-
-	resp, err := http.Get(rawURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch plugin: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to fetch plugin: HTTP %d", resp.StatusCode)
-	}
-
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read plugin: %w", err)
+func (m *Manager) addScriptPlugin(owner, repo, pluginName string, opts installOptions) error {
+	// A pinned version is fetched from its tag, and only from its tag: main
+	// may hold any other version
+	var rawURL string
+	var content []byte
+	refs := scriptRefs(opts.version)
+	for i, ref := range refs {
+		rawURL = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/prism-plugin-%s.sh", owner, repo, ref, pluginName)
+		fmt.Printf("Fetching script from: %s\n", rawURL)
+
+		resp, err := http.Get(rawURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch plugin: %w", err)
+		}
+		if resp.StatusCode == http.StatusNotFound && i < len(refs)-1 {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode == http.StatusNotFound && opts.version != "" {
+			resp.Body.Close()
+			return fmt.Errorf("version %s not found: no tag %s in %s/%s", opts.version, strings.Join(refs, " or "), owner, repo)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("failed to fetch plugin: HTTP %d", resp.StatusCode)
+		}
+		content, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read plugin: %w", err)
+		}
+		break
 	}
 
 	// Validate it's a prism plugin
@@ -416,6 +533,14 @@ func (m *Manager) addScriptPlugin(owner, repo, pluginName string) error {
 		return fmt.Errorf("file doesn't appear to be a Prism plugin (missing @prism-plugin header)")
 	}
 
+	v, err := m.verifyURL(http.DefaultClient, rawURL, content)
+	if err == nil {
+		err = opts.checkPinned(v.sha256)
+	}
+	if err != nil {
+		return &VerifyError{Plugin: pluginName, Err: err}
+	}
+
 	// Write to temp file to parse metadata
 	tmpFile, err := os.CreateTemp("", "prism-plugin-*")
 	if err != nil {
@@ -436,6 +561,32 @@ func (m *Manager) addScriptPlugin(owner, repo, pluginName string) error {
 		meta.Name = pluginName
 	}
 
+	// A manifest next to the script takes precedence over its header
+	manifestURL := rawURL[:strings.LastIndex(rawURL, "/")+1] + ManifestFile
+	manifest, manifestData, err := fetchManifest(http.DefaultClient, manifestURL)
+	if err != nil && !errors.Is(err, errNoManifest) {
+		return err
+	}
+	if manifest != nil {
+		// It sets what the plugin may run, so it is verified like the script
+		mv, err := m.verifyURL(http.DefaultClient, manifestURL, manifestData)
+		if err != nil {
+			return &VerifyError{Plugin: pluginName, Err: err}
+		}
+		v = v.weakest(mv)
+		if err := manifest.Compatible(); err != nil {
+			return err
+		}
+		manifest.Name = meta.Name
+		if manifest.Version == "" {
+			manifest.Version = meta.Version
+		}
+		if manifest.UpdateURL == "" {
+			manifest.UpdateURL = meta.UpdateURL
+		}
+		meta = *manifest
+	}
+
 	// Install
 	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
 		return err
@@ -443,20 +594,124 @@ func (m *Manager) addScriptPlugin(owner, repo, pluginName string) error {
 
 	destPath := filepath.Join(m.pluginDir, fmt.Sprintf("prism-plugin-%s.sh", meta.Name))
 
-	if err := m.checkExistingPlugin(destPath, meta.Name); err != nil {
-		return err
+	if !opts.overwrite {
+		if err := m.checkExistingPlugin(destPath, meta.Name); err != nil {
+			return err
+		}
 	}
 
 	if err := os.WriteFile(destPath, content, 0755); err != nil {
 		return fmt.Errorf("failed to write plugin: %w", err)
 	}
+	if meta.Source == "" {
+		meta.Source = fmt.Sprintf("https://github.com/%s/%s", owner, repo)
+	}
+	meta.SHA256, meta.Verified = v.sha256, v.verified
+	m.saveBinaryMetadata(destPath, meta)
 
-	fmt.Printf("Installed: %s v%s (script)\n", meta.Name, meta.Version)
+	fmt.Printf("Installed: %s v%s (script, %s)\n", meta.Name, meta.Version, describeVerified(meta))
+	warnMissingCommands(meta)
 	return nil
 }
 
+// errNoManifest means a plugin publishes no manifest, which is allowed
+var errNoManifest = errors.New("no manifest")
+
+// fetchManifest downloads and validates a prism-plugin.json, returning its
+// raw content for verification too
+func fetchManifest(client *http.Client, url string) (*Metadata, []byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", ManifestFile, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, errNoManifest
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to fetch %s: HTTP %d", ManifestFile, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", ManifestFile, err)
+	}
+	meta, err := ParseManifest(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &meta, data, nil
+}
+
+// fetchRelease fetches a GitHub release: the latest, or the one tagged
+// version (with or without a "v" prefix)
+func fetchRelease(client *http.Client, owner, repo, version string) (*githubRelease, error) {
+	releaseURLs := []string{fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)}
+	if version != "" {
+		releaseURLs = []string{
+			fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/v%s", owner, repo, version),
+			fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", owner, repo, version),
+		}
+	}
+
+	for _, releaseURL := range releaseURLs {
+		req, err := http.NewRequest("GET", releaseURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			continue
+		}
+
+		var release githubRelease
+		err = json.NewDecoder(resp.Body).Decode(&release)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	if version != "" {
+		return nil, fmt.Errorf("no release %s found", version)
+	}
+	return nil, fmt.Errorf("no releases found")
+}
+
+// githubRelease is the part of a GitHub release the add paths use
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// scriptRefs are the git refs to fetch a script plugin from, in order: the
+// tags of a pinned version, or main
+func scriptRefs(version string) []string {
+	if version == "" {
+		return []string{"main"}
+	}
+	return []string{"v" + version, version}
+}
+
+// warnMissingCommands tells the user about required commands to install
+func warnMissingCommands(meta Metadata) {
+	if missing := meta.MissingCommands(); len(missing) > 0 {
+		fmt.Printf("Warning: %s needs %s on PATH; its section stays hidden until then\n", meta.Name, strings.Join(missing, ", "))
+	}
+}
+
 // addFromDirectURL downloads a plugin from a direct URL
-func (m *Manager) addFromDirectURL(url string) error {
+func (m *Manager) addFromDirectURL(url string, opts installOptions) error {
 	fmt.Printf("Fetching plugin from: %s\n", url)
 
 	resp, err := http.Get(url)
@@ -474,17 +729,18 @@ func (m *Manager) addFromDirectURL(url string) error {
 		return fmt.Errorf("failed to read plugin: %w", err)
 	}
 
+	v, err := m.verifyURL(http.DefaultClient, url, content)
+	if err == nil {
+		err = opts.checkPinned(v.sha256)
+	}
+	if err != nil {
+		return &VerifyError{Plugin: filepath.Base(url), Err: err}
+	}
+
 	// Determine if binary or script
 	isScript := bytes.Contains(content, []byte("@prism-plugin")) || bytes.HasPrefix(content, []byte("#!"))
 
-	// Extract name from URL
-	base := filepath.Base(url)
-	pluginName := strings.TrimPrefix(base, "prism-plugin-")
-	pluginName = strings.TrimSuffix(pluginName, ".sh")
-	// Remove platform suffix if present
-	for _, suffix := range []string{"-darwin-arm64", "-darwin-amd64", "-linux-amd64", "-linux-arm64"} {
-		pluginName = strings.TrimSuffix(pluginName, suffix)
-	}
+	pluginName := sourceName(url)
 
 	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
 		return err
@@ -497,23 +753,30 @@ func (m *Manager) addFromDirectURL(url string) error {
 		destPath = filepath.Join(m.pluginDir, fmt.Sprintf("prism-plugin-%s", pluginName))
 	}
 
-	if err := m.checkExistingPlugin(destPath, pluginName); err != nil {
-		return err
+	if !opts.overwrite {
+		if err := m.checkExistingPlugin(destPath, pluginName); err != nil {
+			return err
+		}
 	}
 
 	if err := os.WriteFile(destPath, content, 0755); err != nil {
 		return fmt.Errorf("failed to write plugin: %w", err)
 	}
 
-	pluginType := "script"
-	if !isScript {
-		pluginType = "binary"
-		// Save basic metadata for binary
-		meta := Metadata{Name: pluginName, Source: url}
-		m.saveBinaryMetadata(destPath, meta)
+	// Save basic metadata, with the digest to check before every run
+	pluginType := "binary"
+	meta := Metadata{Name: pluginName, Source: url}
+	if isScript {
+		pluginType = "script"
+		if header, err := ParseMetadata(destPath); err == nil {
+			meta = header
+			meta.Name, meta.Source = pluginName, url
+		}
 	}
+	meta.SHA256, meta.Verified = v.sha256, v.verified
+	m.saveBinaryMetadata(destPath, meta)
 
-	fmt.Printf("Installed: %s (%s)\n", pluginName, pluginType)
+	fmt.Printf("Installed: %s (%s, %s)\n", pluginName, pluginType, describeVerified(meta))
 	return nil
 }
 
@@ -668,35 +931,15 @@ func (m *Manager) updatePlugin(p Plugin) error {
 }
 
 func (m *Manager) updateBinaryPlugin(p Plugin, client *http.Client) error {
-	// UpdateURL for binaries points to GitHub releases API
-	req, err := http.NewRequest("GET", p.Metadata.UpdateURL, nil)
-	if err != nil {
-		fmt.Printf("  %s: request failed\n", p.Name)
+	owner, repo, ok := githubRepo(p.Metadata.Source)
+	if !ok {
+		fmt.Printf("  %s: no GitHub source to update from\n", p.Name)
 		return nil
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := client.Do(req)
+	release, err := fetchRelease(client, owner, repo, "")
 	if err != nil {
-		fmt.Printf("  %s: fetch failed\n", p.Name)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("  %s: no releases found\n", p.Name)
-		return nil
-	}
-
-	var release struct {
-		TagName string `json:"tag_name"`
-		Assets  []struct {
-			Name               string `json:"name"`
-			BrowserDownloadURL string `json:"browser_download_url"`
-		} `json:"assets"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		fmt.Printf("  %s: parse failed\n", p.Name)
+		fmt.Printf("  %s: %v\n", p.Name, err)
 		return nil
 	}
 
@@ -706,46 +949,14 @@ func (m *Manager) updateBinaryPlugin(p Plugin, client *http.Client) error {
 		return nil
 	}
 
-	// Find binary for our platform
-	osName := runtime.GOOS
-	arch := runtime.GOARCH
-	binaryName := fmt.Sprintf("prism-plugin-%s-%s-%s", p.Name, osName, arch)
-
-	var downloadURL string
-	for _, asset := range release.Assets {
-		if asset.Name == binaryName {
-			downloadURL = asset.BrowserDownloadURL
-			break
-		}
-	}
-
-	if downloadURL == "" {
-		fmt.Printf("  %s: no binary for %s-%s\n", p.Name, osName, arch)
-		return nil
-	}
-
-	// Download new binary
-	resp, err = client.Get(downloadURL)
-	if err != nil {
-		fmt.Printf("  %s: download failed\n", p.Name)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("  %s: read failed\n", p.Name)
-		return nil
-	}
-
-	if err := os.WriteFile(p.Path, content, 0755); err != nil {
-		return fmt.Errorf("failed to update %s: %w", p.Name, err)
+	// Install it like add does, so the new release's manifest replaces the
+	// old one and is checked and verified the same way
+	opts := installOptions{version: remoteVersion, overwrite: true}
+	if err := m.addBinaryPlugin(owner, repo, p.Name, opts); err != nil {
+		fmt.Printf("  %s: not updated: %v\n", p.Name, err)
+		return err
 	}
 
-	// Update metadata
-	p.Metadata.Version = remoteVersion
-	m.saveBinaryMetadata(p.Path, p.Metadata)
-
 	fmt.Printf("  %s: updated %s -> %s\n", p.Name, p.Metadata.Version, remoteVersion)
 	return nil
 }
@@ -770,9 +981,21 @@ func (m *Manager) updateScriptPlugin(p Plugin, client *http.Client) error {
 
 	remoteVersion := strings.TrimSpace(string(matches[1]))
 	if CompareVersions(p.Metadata.Version, remoteVersion) < 0 {
+		v, err := m.verifyURL(client, p.Metadata.UpdateURL, content)
+		if err != nil {
+			fmt.Printf("  %s: not updated: %v\n", p.Name, err)
+			return &VerifyError{Plugin: p.Name, Err: err}
+		}
 		if err := os.WriteFile(p.Path, content, 0755); err != nil {
 			return fmt.Errorf("failed to update %s: %w", p.Name, err)
 		}
+		// Record the new digest, or it would no longer run
+		if _, err := os.Stat(p.Path + ".json"); err == nil || p.Metadata.SHA256 != "" {
+			updated := p.Metadata
+			updated.Version = remoteVersion
+			updated.SHA256, updated.Verified = v.sha256, v.verified
+			m.saveBinaryMetadata(p.Path, updated)
+		}
 		fmt.Printf("  %s: updated %s -> %s\n", p.Name, p.Metadata.Version, remoteVersion)
 	} else {
 		fmt.Printf("  %s: already up to date (%s)\n", p.Name, p.Metadata.Version)
@@ -794,6 +1017,7 @@ func (m *Manager) Remove(name string) error {
 		os.Remove(binaryPath + ".json")
 	} else if _, err := os.Stat(scriptPath); err == nil {
 		path = scriptPath
+		os.Remove(scriptPath + ".json")
 	} else {
 		return fmt.Errorf("plugin '%s' not found", name)
 	}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/himattm/prism/internal/version"
+)
+
+// ManifestFile is the manifest a plugin publishes next to its code or as a
+// release asset. It has the same fields as Metadata, and is installed as
+// the plugin's sidecar .json.
+const ManifestFile = "prism-plugin.json"
+
+// ConfigOption declares one plugin config key in a manifest
+type ConfigOption struct {
+	Type        string `json:"type"` // string, number, boolean, array or object
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// IncompatibleError means a plugin cannot run with this Prism, OS or architecture
+type IncompatibleError struct {
+	Plugin string
+	Reason string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("plugin %s %s", e.Plugin, e.Reason)
+}
+
+// ParseManifest parses and validates a prism-plugin.json
+func ParseManifest(data []byte) (Metadata, error) {
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Metadata{}, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+
+	var problems []string
+	if meta.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if meta.Protocol != "" && meta.Protocol != ProtocolPersistent {
+		problems = append(problems, fmt.Sprintf("unknown protocol %q (use %q or leave it out)", meta.Protocol, ProtocolPersistent))
+	}
+	for _, h := range meta.Hooks {
+		if !contains(HookTypes, h) {
+			problems = append(problems, fmt.Sprintf("unknown hook %q (use %s)", h, strings.Join(HookTypes, ", ")))
+		}
+	}
+	for key, opt := range meta.Config {
+		if !contains(configTypes, opt.Type) {
+			problems = append(problems, fmt.Sprintf("config.%s: unknown type %q (use %s)", key, opt.Type, strings.Join(configTypes, ", ")))
+		} else if opt.Default != nil && !hasType(opt.Default, opt.Type) {
+			problems = append(problems, fmt.Sprintf("config.%s: default %v is not a %s", key, opt.Default, opt.Type))
+		}
+	}
+	if meta.Timeout < 0 {
+		problems = append(problems, "timeout must not be negative")
+	}
+	if meta.MinPrism != "" && meta.MaxPrism != "" && CompareVersions(meta.MinPrism, meta.MaxPrism) > 0 {
+		problems = append(problems, fmt.Sprintf("min_prism %s is above max_prism %s", meta.MinPrism, meta.MaxPrism))
+	}
+
+	if len(problems) > 0 {
+		return Metadata{}, fmt.Errorf("invalid %s: %s", ManifestFile, strings.Join(problems, "; "))
+	}
+	return meta, nil
+}
+
+// Compatible reports whether the plugin supports this Prism version, OS and
+// architecture
+func (meta Metadata) Compatible() error {
+	incompatible := func(format string, args ...any) error {
+		return &IncompatibleError{Plugin: meta.Name, Reason: fmt.Sprintf(format, args...)}
+	}
+	if meta.MinPrism != "" && CompareVersions(version.Version, meta.MinPrism) < 0 {
+		return incompatible("needs Prism %s or newer (this is %s)", meta.MinPrism, version.Version)
+	}
+	if meta.MaxPrism != "" && CompareVersions(version.Version, meta.MaxPrism) > 0 {
+		return incompatible("supports Prism up to %s (this is %s)", meta.MaxPrism, version.Version)
+	}
+	if len(meta.OS) > 0 && !contains(meta.OS, runtime.GOOS) {
+		return incompatible("does not support %s (only %s)", runtime.GOOS, strings.Join(meta.OS, ", "))
+	}
+	if len(meta.Arch) > 0 && !contains(meta.Arch, runtime.GOARCH) {
+		return incompatible("does not support %s (only %s)", runtime.GOARCH, strings.Join(meta.Arch, ", "))
+	}
+	return nil
+}
+
+// MissingCommands returns the required commands that are not on PATH
+func (meta Metadata) MissingCommands() []string {
+	var missing []string
+	for _, name := range meta.Requires {
+		if _, err := exec.LookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Check reports why the plugin cannot run here, or nil if it can
+func (meta Metadata) Check() error {
+	return meta.check(meta.MissingCommands())
+}
+
+// check is Check given the required commands that are not on PATH
+func (meta Metadata) check(missing []string) error {
+	if err := meta.Compatible(); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("plugin %s needs %s on PATH", meta.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// RunTimeout returns the manifest's timeout, or fallback when it sets none
+func (meta Metadata) RunTimeout(fallback time.Duration) time.Duration {
+	if meta.Timeout > 0 {
+		return time.Duration(meta.Timeout) * time.Millisecond
+	}
+	return fallback
+}
+
+// ApplyDefaults returns cfg with the manifest's defaults filled in for keys
+// it does not set. cfg is not modified.
+func (meta Metadata) ApplyDefaults(cfg map[string]any) map[string]any {
+	merged := make(map[string]any, len(cfg)+len(meta.Config))
+	for key, opt := range meta.Config {
+		if opt.Default != nil {
+			merged[key] = opt.Default
+		}
+	}
+	for key, value := range cfg {
+		merged[key] = value
+	}
+	return merged
+}
+
+// ValidateConfig returns problems with cfg against the manifest's config
+// schema, sorted by key. Keys the schema does not declare are allowed.
+func (meta Metadata) ValidateConfig(cfg map[string]any) []string {
+	keys := make([]string, 0, len(cfg))
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		value := cfg[key]
+		opt, ok := meta.Config[key]
+		if ok && !hasType(value, opt.Type) {
+			problems = append(problems, fmt.Sprintf("%s: %v is not a %s", key, value, opt.Type))
+		}
+	}
+	return problems
+}
+
+var configTypes = []string{"string", "number", "boolean", "array", "object"}
+
+// hasType reports whether a decoded JSON value has the schema type
+func hasType(value any, typ string) bool {
+	switch value.(type) {
+	case string:
+		return typ == "string"
+	case float64, int:
+		return typ == "number"
+	case bool:
+		return typ == "boolean"
+	case []any:
+		return typ == "array"
+	case map[string]any:
+		return typ == "object"
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
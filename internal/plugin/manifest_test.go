@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/himattm/prism/internal/version"
+)
+
+func TestParseManifest(t *testing.T) {
+	meta, err := ParseManifest([]byte(`{
+		"name": "builds",
+		"version": "1.2.0",
+		"min_prism": "0.4.0",
+		"os": ["darwin", "linux"],
+		"requires": ["gh"],
+		"hooks": ["idle"],
+		"timeout": 2000,
+		"config": {"branch": {"type": "string", "default": "main"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "builds" || meta.Requires[0] != "gh" || meta.Config["branch"].Default != "main" {
+		t.Errorf("unexpected manifest %+v", meta)
+	}
+	if got := meta.RunTimeout(5 * time.Second); got != 2*time.Second {
+		t.Errorf("RunTimeout = %s, want 2s", got)
+	}
+}
+
+func TestParseManifest_Invalid(t *testing.T) {
+	tests := map[string]string{
+		`{"version": "1.0.0"}`:                                               "name is required",
+		`{"name": "x", "protocol": "grpc"}`:                                  "unknown protocol",
+		`{"name": "x", "hooks": ["idle", "compact"]}`:                        `unknown hook "compact"`,
+		`{"name": "x", "config": {"n": {"type": "integer"}}}`:                `unknown type "integer"`,
+		`{"name": "x", "config": {"n": {"type": "number", "default": "5"}}}`: "is not a number",
+		`{"name": "x", "min_prism": "2.0", "max_prism": "1.0"}`:              "above max_prism",
+		`{"name": "x", "timeout": -1}`:                                       "timeout",
+		`not json`:                                                           "invalid",
+	}
+	for data, want := range tests {
+		if _, err := ParseManifest([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseManifest(%s) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		name string
+		meta Metadata
+		ok   bool
+	}{
+		{"no constraints", Metadata{}, true},
+		{"in range", Metadata{MinPrism: "0.1.0", MaxPrism: "99.0.0"}, true},
+		{"too old", Metadata{MinPrism: "99.0.0"}, false},
+		{"too new", Metadata{MaxPrism: "0.0.1"}, false},
+		{"this OS", Metadata{OS: []string{runtime.GOOS}}, true},
+		{"other OS", Metadata{OS: []string{"plan9"}}, false},
+		{"other arch", Metadata{Arch: []string{"mips"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.meta.Compatible()
+			if (err == nil) != tt.ok {
+				t.Errorf("Compatible() = %v, want ok=%v", err, tt.ok)
+			}
+			var incompatible *IncompatibleError
+			if err != nil && !errors.As(err, &incompatible) {
+				t.Errorf("error %v is not an IncompatibleError", err)
+			}
+		})
+	}
+
+	err := Metadata{Name: "x", MinPrism: "99.0.0"}.Compatible()
+	if err == nil || !strings.Contains(err.Error(), version.Version) {
+		t.Errorf("error %v should name the running version", err)
+	}
+}
+
+func TestCheck_MissingCommands(t *testing.T) {
+	meta := Metadata{Name: "ios", Requires: []string{"sh", "prism-no-such-command"}}
+	if got := meta.MissingCommands(); len(got) != 1 || got[0] != "prism-no-such-command" {
+		t.Errorf("MissingCommands = %v", got)
+	}
+	if err := meta.Check(); err == nil || !strings.Contains(err.Error(), "prism-no-such-command") {
+		t.Errorf("Check = %v", err)
+	}
+}
+
+func TestRunnable_CachesCommandLookups(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	m := &Manager{pluginDir: t.TempDir()}
+	p := Plugin{Name: "ios", Metadata: Metadata{Name: "ios", Requires: []string{"xcrun"}}}
+
+	if err := m.Runnable(p, false); err == nil || !strings.Contains(err.Error(), "xcrun") {
+		t.Fatalf("Runnable = %v, want xcrun missing", err)
+	}
+	os.WriteFile(filepath.Join(bin, "xcrun"), []byte("#!/bin/sh\n"), 0755)
+	if err := m.Runnable(p, false); err == nil {
+		t.Error("Runnable looked up xcrun again instead of using the cached result")
+	}
+	if missing := p.Metadata.MissingCommands(); len(missing) != 0 {
+		t.Errorf("MissingCommands = %v, want a fresh lookup", missing)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	meta := Metadata{Config: map[string]ConfigOption{
+		"branch": {Type: "string", Default: "main"},
+		"limit":  {Type: "number", Default: float64(3)},
+		"token":  {Type: "string"},
+	}}
+	cfg := map[string]any{"limit": float64(10)}
+
+	got := meta.ApplyDefaults(cfg)
+	if got["branch"] != "main" || got["limit"] != float64(10) {
+		t.Errorf("ApplyDefaults = %v", got)
+	}
+	if _, ok := got["token"]; ok {
+		t.Error("keys without a default should stay unset")
+	}
+	if len(cfg) != 1 {
+		t.Error("ApplyDefaults modified its argument")
+	}
+
+	if problems := meta.ValidateConfig(map[string]any{"limit": "ten", "extra": true}); len(problems) != 1 {
+		t.Errorf("ValidateConfig = %v, want one problem", problems)
+	}
+	problems := meta.ValidateConfig(map[string]any{"limit": "ten", "branch": false, "token": 1.0})
+	if got := strings.Join(problems, "; "); got != "branch: false is not a string; limit: ten is not a number; token: 1 is not a string" {
+		t.Errorf("ValidateConfig = %q, want problems sorted by key", got)
+	}
+}
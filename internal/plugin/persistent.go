@@ -0,0 +1,477 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/himattm/prism/internal/logging"
+)
+
+// ProtocolPersistent marks a plugin (@protocol persistent) that is started
+// once and answers newline-delimited JSON-RPC 2.0 requests on stdin/stdout,
+// instead of being run for every render
+const ProtocolPersistent = "persistent"
+
+// Restart backoff for persistent plugins that crash; vars so tests can shorten them
+var (
+	// restartBackoff is the wait before the first restart, doubled per crash
+	restartBackoff = 1 * time.Second
+	// maxRestartBackoff caps the wait between restarts
+	maxRestartBackoff = 1 * time.Minute
+	// stableRun is how long a plugin must run for its crash count to reset
+	stableRun = 1 * time.Minute
+	// stopGrace is how long a plugin has to exit after its stdin closes
+	stopGrace = 1 * time.Second
+	// outputGrace is how long output is still read after a plugin exits
+	outputGrace = 100 * time.Millisecond
+)
+
+// maxResponseLine is the longest response or stderr line a persistent plugin
+// may write
+const maxResponseLine = 1 << 20
+
+// HookParams are the params of a "hook" request
+type HookParams struct {
+	Type      string         `json:"type"` // idle, busy, session_start, session_end or pre_compact
+	SessionID string         `json:"session_id"`
+	Config    map[string]any `json:"config"`
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// supervised keeps one persistent plugin running, restarting it with
+// backoff when it crashes
+type supervised struct {
+	path string
+
+	mu      sync.Mutex
+	proc    *process  // nil while stopped or waiting to restart
+	crashes int       // Consecutive crashes
+	retryAt time.Time // No restart before this
+	lastErr error     // Why it last exited or failed to start
+	closed  bool      // Stopped by Close; never restarted
+}
+
+// process is one running instance of a persistent plugin
+type process struct {
+	cmd     *exec.Cmd
+	modTime time.Time // Of the plugin file, to restart after an update
+	started time.Time
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResponse
+	stderr  string // Last line written to stderr
+
+	done chan struct{} // Closed when the process exits
+	err  error         // Exit error, set before done is closed
+}
+
+// call sends a request to a persistent plugin, starting it first if needed,
+// and waits up to timeout for the response. A request that times out does
+// not stop the plugin; its late answer is dropped.
+func (m *Manager) call(p Plugin, method string, params any, timeout time.Duration) (json.RawMessage, error) {
+	proc, err := m.supervisor(p.Path).get()
+	if err != nil {
+		return nil, err
+	}
+
+	proc.mu.Lock()
+	proc.nextID++
+	id := proc.nextID
+	reply := make(chan rpcResponse, 1)
+	proc.pending[id] = reply
+	proc.mu.Unlock()
+	defer func() {
+		proc.mu.Lock()
+		delete(proc.pending, id)
+		proc.mu.Unlock()
+	}()
+
+	line, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	proc.writeMu.Lock()
+	_, err = proc.stdin.Write(append(line, '\n'))
+	proc.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("plugin not accepting requests: %w", err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("plugin error %d: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return resp.Result, nil
+	case <-proc.done:
+		return nil, fmt.Errorf("plugin exited: %v", proc.err)
+	case <-timer.C:
+		return nil, fmt.Errorf("plugin timed out")
+	}
+}
+
+// executePersistent answers a render request from a persistent plugin
+func (m *Manager) executePersistent(p Plugin, input Input, timeout time.Duration) (string, error) {
+	result, err := m.call(p, "render", input, timeout)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(result)
+}
+
+// Hook sends a hook event to a persistent plugin and returns its
+// notification text, if any
+func (m *Manager) Hook(p Plugin, params HookParams, timeout time.Duration) (string, error) {
+	if p.Metadata.Protocol != ProtocolPersistent {
+		return "", fmt.Errorf("plugin %s is not persistent", p.Name)
+	}
+	result, err := m.call(p, "hook", params, timeout)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(result)
+}
+
+// Running reports whether a persistent plugin has a live process
+func (m *Manager) Running(p Plugin) bool {
+	m.mu.Lock()
+	s := m.running[p.Path]
+	m.mu.Unlock()
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proc != nil
+}
+
+// StartedPersistent reports whether the manager has started (or tried to
+// start) a persistent plugin
+func (m *Manager) StartedPersistent() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.running) > 0
+}
+
+// Close stops every persistent plugin the manager started
+func (m *Manager) Close() {
+	m.mu.Lock()
+	running := m.running
+	m.running = nil
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, s := range running {
+		wg.Add(1)
+		go func(s *supervised) {
+			defer wg.Done()
+			s.stop()
+		}(s)
+	}
+	wg.Wait()
+}
+
+func (m *Manager) supervisor(path string) *supervised {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running == nil {
+		m.running = make(map[string]*supervised)
+	}
+	s := m.running[path]
+	if s == nil {
+		s = &supervised{path: path}
+		m.running[path] = s
+	}
+	return s
+}
+
+// decodeText reads a result that is a string, or null for no output
+func decodeText(result json.RawMessage) (string, error) {
+	if len(result) == 0 || string(result) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(result, &text); err != nil {
+		return "", fmt.Errorf("result is not a string: %s", result)
+	}
+	return strings.TrimRight(text, "\n"), nil
+}
+
+// get returns the running process, starting it if needed. While a crashed
+// plugin waits out its backoff, requests fail fast.
+func (s *supervised) get() (*process, error) {
+	s.mu.Lock()
+	proc, replaced, err := s.getLocked()
+	s.mu.Unlock()
+
+	// Stopping can take stopGrace; no render needs to wait for it
+	if replaced != nil {
+		go replaced.stop()
+	}
+	return proc, err
+}
+
+// getLocked is get with s.mu held. It also returns the process replaced
+// because the plugin file changed, for get to stop after unlocking.
+func (s *supervised) getLocked() (proc, replaced *process, err error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.proc != nil {
+		if info.ModTime().Equal(s.proc.modTime) {
+			return s.proc, nil, nil
+		}
+		// The plugin was updated: replace the old process
+		logging.Info("plugin.restart", "path", s.path, "reason", "file changed")
+		replaced = s.proc
+		s.proc = nil
+		s.crashes = 0
+	}
+
+	if wait := time.Until(s.retryAt); wait > 0 {
+		return nil, replaced, fmt.Errorf("plugin restarting in %s after crash: %v", wait.Round(100*time.Millisecond), s.lastErr)
+	}
+	if err := s.startLocked(info.ModTime()); err != nil {
+		return nil, replaced, err
+	}
+	return s.proc, replaced, nil
+}
+
+func (s *supervised) startLocked(modTime time.Time) error {
+	proc, err := start(s.path, modTime)
+	if err != nil {
+		s.lastErr = err
+		s.backoff()
+		logging.Warn("plugin.start", "path", s.path, "error", err)
+		return err
+	}
+	s.proc = proc
+	logging.Info("plugin.start", "path", s.path, "pid", proc.cmd.Process.Pid)
+	go s.watch(proc)
+	return nil
+}
+
+// watch restarts the plugin after backoff if proc exits on its own
+func (s *supervised) watch(proc *process) {
+	<-proc.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc != proc {
+		return // Stopped or replaced on purpose
+	}
+	s.proc = nil
+	if time.Since(proc.started) >= stableRun {
+		s.crashes = 0
+	}
+	s.lastErr = proc.err
+	wait := s.backoff()
+	logging.Warn("plugin.crash", "path", s.path, "error", proc.err, "stderr", proc.stderr, "crashes", s.crashes, "restart_in", wait)
+
+	// Restart without waiting for a request, so slow starters are warm again
+	time.AfterFunc(wait, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.closed || s.proc != nil {
+			return
+		}
+		info, err := os.Stat(s.path)
+		if err != nil {
+			return
+		}
+		s.startLocked(info.ModTime())
+	})
+}
+
+// backoff counts a failure and returns how long to wait before the next start
+func (s *supervised) backoff() time.Duration {
+	s.crashes++
+	wait := restartBackoff
+	for i := 1; i < s.crashes && wait < maxRestartBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRestartBackoff {
+		wait = maxRestartBackoff
+	}
+	s.retryAt = time.Now().Add(wait)
+	return wait
+}
+
+func (s *supervised) stop() {
+	s.mu.Lock()
+	s.closed = true
+	proc := s.proc
+	s.proc = nil
+	s.mu.Unlock()
+
+	if proc != nil {
+		proc.stop()
+	}
+}
+
+func start(path string, modTime time.Time) (*process, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Our own pipes rather than cmd.StdoutPipe, so cmd.Wait returns when the
+	// plugin exits even if a child it started still holds them open
+	stdout, stdoutW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, stderrW, err := os.Pipe()
+	if err != nil {
+		stdout.Close()
+		stdoutW.Close()
+		return nil, err
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	err = cmd.Start()
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
+		return nil, fmt.Errorf("failed to start plugin: %w", err)
+	}
+
+	proc := &process{
+		cmd:     cmd,
+		modTime: modTime,
+		started: time.Now(),
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		proc.readResponses(stdout)
+	}()
+	go func() {
+		defer output.Done()
+		proc.readStderr(stderr)
+	}()
+	go func() {
+		proc.err = cmd.Wait()
+		if proc.err == nil {
+			proc.err = fmt.Errorf("exited")
+		}
+
+		// Let the readers pick up the last lines (stderr for the crash
+		// report), but don't wait on a child that kept the pipes
+		outputDone := make(chan struct{})
+		go func() {
+			output.Wait()
+			close(outputDone)
+		}()
+		select {
+		case <-outputDone:
+		case <-time.After(outputGrace):
+		}
+		stdout.Close()
+		stderr.Close()
+		close(proc.done)
+	}()
+	return proc, nil
+}
+
+// readResponses routes each response line to the request waiting for it.
+// A line it cannot read (over 1 MiB) leaves the stream out of step, so the
+// plugin is killed and restarted instead of blocking on a full pipe.
+func (p *process) readResponses(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxResponseLine)
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			logging.Debug("plugin.output", "path", p.cmd.Path, "error", "not a JSON-RPC response", "line", scanner.Text())
+			continue
+		}
+		p.mu.Lock()
+		reply := p.pending[resp.ID]
+		p.mu.Unlock()
+		if reply != nil {
+			select {
+			case reply <- resp:
+			default: // Duplicate response
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		logging.Warn("plugin.output", "path", p.cmd.Path, "error", err)
+		p.cmd.Process.Kill()
+		io.Copy(io.Discard, stdout)
+	}
+}
+
+// readStderr sends plugin stderr to the debug log and keeps the last line
+// for crash reports. After a line it cannot read (over 1 MiB) the rest is
+// discarded, so the plugin never blocks writing to a full pipe.
+func (p *process) readStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 4096), maxResponseLine)
+	for scanner.Scan() {
+		p.mu.Lock()
+		p.stderr = scanner.Text()
+		p.mu.Unlock()
+		logging.Debug("plugin.stderr", "path", p.cmd.Path, "line", scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		logging.Debug("plugin.stderr", "path", p.cmd.Path, "error", err)
+		io.Copy(io.Discard, stderr)
+	}
+}
+
+// stop closes stdin, which asks the plugin to exit, and kills it if it has
+// not exited within stopGrace
+func (p *process) stop() {
+	p.writeMu.Lock()
+	p.stdin.Close()
+	p.writeMu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-time.After(stopGrace):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
@@ -0,0 +1,234 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoPlugin answers render requests with its PID, hook requests with the
+// hook type, and exits on a render for the "crash" session
+const echoPlugin = `#!/bin/sh
+# @name echo
+# @protocol persistent
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+  case "$line" in
+    *'"session_id":"crash"'*) echo "boom" >&2; exit 3 ;;
+    *'"method":"render"'*) printf '{"jsonrpc":"2.0","id":%s,"result":"pid %s"}\n' "$id" "$$" ;;
+    *'"type":"idle"'*) printf '{"jsonrpc":"2.0","id":%s,"result":"went idle"}\n' "$id" ;;
+    *) printf '{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}\n' "$id" ;;
+  esac
+done
+`
+
+func newEchoPlugin(t *testing.T) (*Manager, Plugin) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prism-plugin-echo.sh")
+	if err := os.WriteFile(path, []byte(echoPlugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{pluginDir: dir}
+	t.Cleanup(m.Close)
+
+	plugins, err := m.Discover()
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("Discover = %v, %v", plugins, err)
+	}
+	if plugins[0].Metadata.Protocol != ProtocolPersistent {
+		t.Fatalf("protocol = %q, want persistent", plugins[0].Metadata.Protocol)
+	}
+	return m, plugins[0]
+}
+
+func TestPersistent_StartedOnce(t *testing.T) {
+	m, p := newEchoPlugin(t)
+	if m.StartedPersistent() {
+		t.Error("StartedPersistent before any render")
+	}
+
+	first, err := m.Execute(p, Input{}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("first render: %v", err)
+	}
+	second, err := m.Execute(p, Input{}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("second render: %v", err)
+	}
+	if !strings.HasPrefix(first, "pid ") || first != second {
+		t.Errorf("renders = %q, %q; want the same process to answer both", first, second)
+	}
+	if !m.Running(p) || !m.StartedPersistent() {
+		t.Error("plugin not running between renders")
+	}
+
+	output, err := m.Hook(p, HookParams{Type: "idle"}, 2*time.Second)
+	if err != nil || output != "went idle" {
+		t.Errorf("Hook = %q, %v", output, err)
+	}
+	if _, err := m.Hook(p, HookParams{Type: "busy"}, 2*time.Second); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("Hook error = %v, want the plugin's JSON-RPC error", err)
+	}
+
+	m.Close()
+	if m.Running(p) {
+		t.Error("plugin still running after Close")
+	}
+}
+
+func TestPersistent_RestartsAfterCrash(t *testing.T) {
+	defer func(d time.Duration) { restartBackoff = d }(restartBackoff)
+	restartBackoff = 200 * time.Millisecond
+
+	m, p := newEchoPlugin(t)
+
+	before, err := m.Execute(p, Input{}, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crash := Input{Prism: PrismContext{SessionID: "crash"}}
+	if _, err := m.Execute(p, crash, 2*time.Second); err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Fatalf("crash render error = %v, want plugin exited", err)
+	}
+
+	// Requests fail fast during the backoff...
+	time.Sleep(50 * time.Millisecond)
+	if _, err := m.Execute(p, Input{}, 2*time.Second); err == nil || !strings.Contains(err.Error(), "restarting") {
+		t.Errorf("render during backoff error = %v, want restarting", err)
+	}
+
+	// ...then the supervisor restarts it on its own
+	deadline := time.Now().Add(2 * time.Second)
+	for !m.Running(p) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	after, err := m.Execute(p, Input{}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("render after restart: %v", err)
+	}
+	if after == before {
+		t.Errorf("render after crash = %q, want a new process", after)
+	}
+}
+
+func TestPersistent_Backoff(t *testing.T) {
+	s := &supervised{}
+	var waits []time.Duration
+	for i := 0; i < 9; i++ {
+		waits = append(waits, s.backoff())
+	}
+	if waits[0] != restartBackoff || waits[1] != 2*restartBackoff || waits[2] != 4*restartBackoff {
+		t.Errorf("backoff = %v, want doubling from %s", waits, restartBackoff)
+	}
+	if waits[8] != maxRestartBackoff {
+		t.Errorf("backoff after 9 crashes = %s, want capped at %s", waits[8], maxRestartBackoff)
+	}
+}
+
+// writePersistent installs a persistent script plugin and returns it
+func writePersistent(t *testing.T, script string) (*Manager, Plugin) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prism-plugin-p.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{pluginDir: dir}
+	t.Cleanup(m.Close)
+	plugins, err := m.Discover()
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("Discover = %v, %v", plugins, err)
+	}
+	return m, plugins[0]
+}
+
+func TestPersistent_KilledOnOversizedLine(t *testing.T) {
+	// Answers with a line over the limit, then keeps writing
+	m, p := writePersistent(t, `#!/bin/sh
+# @protocol persistent
+read -r line
+head -c 1100000 /dev/zero | tr '\0' 'x'
+while :; do echo more; done
+`)
+
+	start := time.Now()
+	_, err := m.Execute(p, Input{}, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("render error = %v, want the plugin killed", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("render took %s, want the plugin killed right away", elapsed)
+	}
+}
+
+func TestPersistent_DrainsOversizedStderr(t *testing.T) {
+	// Writes a stderr line over the limit, then more than a pipe holds
+	m, p := writePersistent(t, `#!/bin/sh
+# @protocol persistent
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+  head -c 1100000 /dev/zero | tr '\0' 'x' >&2
+  echo >&2
+  head -c 200000 /dev/zero | tr '\0' 'y' >&2
+  printf '{"jsonrpc":"2.0","id":%s,"result":"ok"}\n' "$id"
+done
+`)
+
+	for i := 0; i < 2; i++ {
+		if out, err := m.Execute(p, Input{}, 5*time.Second); err != nil || out != "ok" {
+			t.Fatalf("render %d = %q, %v; want the plugin not blocked on stderr", i, out, err)
+		}
+	}
+}
+
+func TestPersistent_ReplacedWithoutWaitingForStop(t *testing.T) {
+	// Keeps running after its stdin closes, so stopping it takes stopGrace
+	m, p := writePersistent(t, `#!/bin/sh
+# @protocol persistent
+while :; do
+  if IFS= read -r line; then
+    id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+    printf '{"jsonrpc":"2.0","id":%s,"result":"ok"}\n' "$id"
+  else
+    sleep 0.1
+  fi
+done
+`)
+	if _, err := m.Execute(p, Input{}, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(p.Path, later, later)
+	start := time.Now()
+	if _, err := m.Execute(p, Input{}, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= stopGrace {
+		t.Errorf("render after a file change took %s, want it not to wait for the old process", elapsed)
+	}
+}
+
+func TestPersistent_StopIgnoresInheritedPipes(t *testing.T) {
+	// A child that outlives the plugin keeps its stdout and stderr open
+	m, p := writePersistent(t, `#!/bin/sh
+# @protocol persistent
+sleep 5 &
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+  printf '{"jsonrpc":"2.0","id":%s,"result":"ok"}\n' "$id"
+done
+`)
+
+	if _, err := m.Execute(p, Input{}, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	m.Close()
+	if elapsed := time.Since(start); elapsed > stopGrace {
+		t.Errorf("Close took %s, want it not to wait for the child", elapsed)
+	}
+}
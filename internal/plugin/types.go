@@ -1,13 +1,29 @@
 package plugin
 
-// Metadata represents plugin header metadata parsed from @-prefixed comments
+// Metadata represents plugin header metadata parsed from @-prefixed comments,
+// or a plugin's manifest (see ManifestFile)
 type Metadata struct {
-	Name        string `json:"name"`
-	Version     string `json:"version"`
-	Description string `json:"description"`
-	Author      string `json:"author"`
-	Source      string `json:"source"`
-	UpdateURL   string `json:"update_url"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Author      string   `json:"author"`
+	Source      string   `json:"source"`
+	UpdateURL   string   `json:"update_url"`
+	Protocol    string   `json:"protocol,omitempty"` // "persistent", or empty to run per render
+	Hooks       []string `json:"hooks,omitempty"`    // Hook events to receive, e.g. "idle" (see Subscribes)
+
+	// Manifest-only fields
+	MinPrism string                  `json:"min_prism,omitempty"` // Oldest supported Prism version
+	MaxPrism string                  `json:"max_prism,omitempty"` // Newest supported Prism version
+	OS       []string                `json:"os,omitempty"`        // GOOS values, e.g. "darwin"; empty for any
+	Arch     []string                `json:"arch,omitempty"`      // GOARCH values, e.g. "arm64"; empty for any
+	Config   map[string]ConfigOption `json:"config,omitempty"`    // Config keys with types and defaults
+	Requires []string                `json:"requires,omitempty"`  // Commands that must be on PATH, e.g. "adb"
+	Timeout  int                     `json:"timeout,omitempty"`   // Milliseconds a run may take (default 5000)
+
+	// Recorded at install
+	SHA256   string `json:"sha256,omitempty"`   // Digest of the installed file; it must still match to run
+	Verified string `json:"verified,omitempty"` // VerifiedChecksum, VerifiedSignature, or empty if unverified
 }
 
 // Input is the JSON structure sent to plugins via stdin
@@ -0,0 +1,398 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/himattm/prism/internal/cache"
+)
+
+// Verified values recorded in Metadata
+const (
+	VerifiedChecksum  = "checksum"  // Matched a published SHA-256 checksum
+	VerifiedSignature = "signature" // ...and the checksums were signed by a pinned key
+)
+
+// digestTTL is how long a plugin file's digest is cached while the file is
+// unchanged
+const digestTTL = 24 * time.Hour
+
+// commandTTL is how long Runnable trusts a lookup of a required command, so
+// a command installed while the daemon runs is found within a minute
+const commandTTL = 1 * time.Minute
+
+// checksumFiles are the release assets searched for SHA-256 checksums,
+// in "<hex>  <file>" format (sha256sum, goreleaser)
+var checksumFiles = []string{"checksums.txt", "SHA256SUMS"}
+
+// VerifyError means a download failed verification and was not installed
+type VerifyError struct {
+	Plugin string
+	Err    error
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("plugin %s failed verification: %v", e.Plugin, e.Err)
+}
+
+func (e *VerifyError) Unwrap() error { return e.Err }
+
+// Trust sets how downloads are verified
+type Trust struct {
+	RequireVerified bool              // Refuse plugins without a published checksum
+	Keys            map[string]string // Publisher (GitHub owner or URL prefix) to PEM public key or key file
+}
+
+// SetTrust sets how Add and Update verify downloads
+func (m *Manager) SetTrust(t Trust) {
+	m.trust = t
+}
+
+// Digest returns the hex SHA-256 of content
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckIntegrity reports whether the plugin file still has the digest
+// recorded when it was installed. Plugins installed without one pass.
+// Digests are cached by path, modification time and size, so a file is
+// only hashed again once it changed.
+func (m *Manager) CheckIntegrity(p Plugin) error {
+	if p.Metadata.SHA256 == "" {
+		return nil
+	}
+	digest, err := m.fileDigest(p.Path)
+	if err != nil {
+		return err
+	}
+	if digest != p.Metadata.SHA256 {
+		return fmt.Errorf("plugin %s was modified after install (sha256 %s, recorded %s); reinstall it with 'prism plugin add'",
+			p.Name, short(digest), short(p.Metadata.SHA256))
+	}
+	return nil
+}
+
+// fileDigest returns the SHA-256 of a file, from the digest cache while
+// the file is unchanged
+func (m *Manager) fileDigest(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	digests := m.digestCache()
+	key := digestKey(path, info)
+	if digest, ok := digests.Get(key); ok {
+		return digest, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	digest := Digest(content)
+	digests.Set(key, digest, digestTTL)
+	digests.Flush()
+	return digest, nil
+}
+
+// digestKey identifies a version of a plugin file in the digest cache
+func digestKey(path string, info os.FileInfo) string {
+	return fmt.Sprintf("%s:%d:%d", path, info.ModTime().UnixNano(), info.Size())
+}
+
+// digestCache returns the cache of file digests, memory-only for managers
+// not made by NewManager
+func (m *Manager) digestCache() *cache.Cache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.digests == nil {
+		m.digests = cache.New()
+	}
+	return m.digests
+}
+
+// Runnable reports why a plugin may not run here: its manifest rules it out,
+// its file changed since install, or it is unverified while requireVerified
+// is set. It returns nil when the plugin may run.
+func (m *Manager) Runnable(p Plugin, requireVerified bool) error {
+	if err := p.Metadata.check(m.missingCommands(p.Metadata)); err != nil {
+		return err
+	}
+	if err := m.CheckIntegrity(p); err != nil {
+		return err
+	}
+	if requireVerified && p.Metadata.Verified == "" {
+		return fmt.Errorf("plugin %s is not verified (pluginTrust.requireVerified is set)", p.Name)
+	}
+	return nil
+}
+
+// missingCommands is meta.MissingCommands with each lookup cached for
+// commandTTL, since Runnable runs for every plugin on every render
+func (m *Manager) missingCommands(meta Metadata) []string {
+	if len(meta.Requires) == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	if m.commands == nil {
+		m.commands = cache.New()
+	}
+	commands := m.commands
+	m.mu.Unlock()
+
+	var missing []string
+	for _, name := range meta.Requires {
+		found, ok := commands.Get(name)
+		if !ok {
+			_, err := exec.LookPath(name)
+			found = strconv.FormatBool(err == nil)
+			commands.Set(name, found, commandTTL)
+		}
+		if found != "true" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// describeVerified summarizes how an installed plugin was verified
+func describeVerified(meta Metadata) string {
+	switch meta.Verified {
+	case VerifiedSignature:
+		return "checksum and signature verified"
+	case VerifiedChecksum:
+		return "checksum verified"
+	}
+	return "unverified, sha256 " + short(meta.SHA256) + " recorded"
+}
+
+// verification is the outcome of verifying a download
+type verification struct {
+	sha256   string
+	verified string // VerifiedChecksum, VerifiedSignature, or empty
+}
+
+// weakest returns v, verified no better than other: an install is only as
+// verified as the least verified file in it
+func (v verification) weakest(other verification) verification {
+	if other.verified == "" || (other.verified == VerifiedChecksum && v.verified == VerifiedSignature) {
+		v.verified = other.verified
+	}
+	return v
+}
+
+// verifyRelease checks a binary downloaded from a GitHub release against
+// the release's checksums file, and that file's signature when the
+// publisher has a pinned key. assets maps asset names to download URLs.
+// The release's manifest, if downloaded, is checked against the same file;
+// without a checksum for it the binary counts as unverified.
+func (m *Manager) verifyRelease(client *http.Client, assets map[string]string, name, source string, content, manifest []byte) (verification, error) {
+	v := verification{sha256: Digest(content)}
+
+	var sumsName, sumsURL string
+	for _, candidate := range checksumFiles {
+		if url, ok := assets[candidate]; ok {
+			sumsName, sumsURL = candidate, url
+			break
+		}
+	}
+	if sumsURL == "" {
+		return v, m.unverified(source, "the release has no "+strings.Join(checksumFiles, " or "))
+	}
+
+	sums, err := fetch(client, sumsURL)
+	if err != nil {
+		return v, fmt.Errorf("failed to fetch %s: %w", sumsName, err)
+	}
+	expected, ok := lookupChecksum(sums, name)
+	if !ok {
+		return v, fmt.Errorf("%s has no checksum for %s", sumsName, name)
+	}
+	if err := matchChecksum(expected, v.sha256, name); err != nil {
+		return v, err
+	}
+	if manifest != nil {
+		expected, ok := lookupChecksum(sums, ManifestFile)
+		if !ok {
+			return v, m.unverified(source, sumsName+" has no checksum for "+ManifestFile)
+		}
+		if err := matchChecksum(expected, Digest(manifest), ManifestFile); err != nil {
+			return v, err
+		}
+	}
+	v.verified = VerifiedChecksum
+
+	return m.verifySigned(client, v, source, sumsName, sums, assets[sumsName+".sig"])
+}
+
+// verifyURL checks a plugin downloaded from url against url.sha256, and
+// that file's signature (url.sha256.sig) when the publisher has a pinned key
+func (m *Manager) verifyURL(client *http.Client, url string, content []byte) (verification, error) {
+	v := verification{sha256: Digest(content)}
+	name := filepath.Base(url)
+
+	sums, err := fetch(client, url+".sha256")
+	if err != nil {
+		return v, m.unverified(url, fmt.Sprintf("no checksum at %s.sha256", url))
+	}
+	fields := strings.Fields(string(sums))
+	if len(fields) == 0 {
+		return v, fmt.Errorf("%s.sha256 is empty", url)
+	}
+	if err := matchChecksum(fields[0], v.sha256, name); err != nil {
+		return v, err
+	}
+	v.verified = VerifiedChecksum
+
+	return m.verifySigned(client, v, url, name+".sha256", sums, url+".sha256.sig")
+}
+
+// verifySigned checks the signature of a checksums file when the
+// publisher of source has a pinned key
+func (m *Manager) verifySigned(client *http.Client, v verification, source, sumsName string, sums []byte, sigURL string) (verification, error) {
+	key, publisher := m.trust.keyFor(source)
+	if key == "" {
+		return v, nil
+	}
+	if sigURL == "" {
+		return v, fmt.Errorf("%s is not signed, but a key is pinned for %s", sumsName, publisher)
+	}
+	sig, err := fetch(client, sigURL)
+	if err != nil {
+		return v, fmt.Errorf("%s is not signed (%v), but a key is pinned for %s", sumsName, err, publisher)
+	}
+	if err := VerifySignature(key, sums, sig); err != nil {
+		return v, fmt.Errorf("signature of %s does not match the key pinned for %s: %w", sumsName, publisher, err)
+	}
+	v.verified = VerifiedSignature
+	return v, nil
+}
+
+// unverified decides whether a download without a checksum may be installed
+func (m *Manager) unverified(source, reason string) error {
+	if _, publisher := m.trust.keyFor(source); publisher != "" {
+		return fmt.Errorf("cannot verify: %s, but a key is pinned for %s", reason, publisher)
+	}
+	if m.trust.RequireVerified {
+		return fmt.Errorf("cannot verify: %s (pluginTrust.requireVerified is set)", reason)
+	}
+	return nil
+}
+
+// keyFor returns the pinned key for the publisher of source, if any
+func (t Trust) keyFor(source string) (key, publisher string) {
+	for name, k := range t.Keys {
+		if strings.HasPrefix(source, "https://github.com/"+name+"/") ||
+			strings.HasPrefix(source, "https://api.github.com/repos/"+name+"/") ||
+			strings.HasPrefix(source, "https://raw.githubusercontent.com/"+name+"/") ||
+			(strings.Contains(name, "://") && strings.HasPrefix(source, name)) {
+			return k, name
+		}
+	}
+	return "", ""
+}
+
+// VerifySignature checks a base64 signature over message (as written by
+// `cosign sign-blob` or `openssl pkeyutl`) with a PEM public key, or the
+// path of a PEM file. ECDSA signatures are over the SHA-256 of message;
+// Ed25519 signatures are over message itself.
+func VerifySignature(key string, message, sig []byte) error {
+	keyPEM := []byte(key)
+	if !strings.Contains(key, "-----BEGIN") {
+		data, err := os.ReadFile(expandHome(key))
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		keyPEM = data
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return fmt.Errorf("key is not PEM")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %w", err)
+	}
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		if !ecdsa.VerifyASN1(pub, digest[:], raw) {
+			return fmt.Errorf("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, message, raw) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T (use ECDSA P-256 or Ed25519)", pub)
+	}
+	return nil
+}
+
+// lookupChecksum finds name in a "<hex>  <file>" checksums file
+func lookupChecksum(sums []byte, name string) (string, bool) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+func matchChecksum(expected, actual, name string) error {
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("checksum mismatch for %s: downloaded %s, published %s", name, short(actual), short(expected))
+	}
+	return nil
+}
+
+func fetch(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func short(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
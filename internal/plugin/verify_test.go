@@ -0,0 +1,263 @@
+package plugin
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/himattm/prism/internal/cache"
+)
+
+const verifyScript = "#!/bin/sh\n# @prism-plugin\n# @version 1.0.0\necho verified\n"
+
+// newPluginServer serves files by path, 404 for anything else
+func newPluginServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAdd_VerifiesChecksum(t *testing.T) {
+	srv := newPluginServer(t, map[string]string{
+		"/prism-plugin-ci.sh":        verifyScript,
+		"/prism-plugin-ci.sh.sha256": Digest([]byte(verifyScript)) + "  prism-plugin-ci.sh\n",
+	})
+	m := &Manager{pluginDir: t.TempDir()}
+
+	if err := m.Add(srv.URL + "/prism-plugin-ci.sh"); err != nil {
+		t.Fatal(err)
+	}
+	plugins, _ := m.Discover()
+	if len(plugins) != 1 {
+		t.Fatalf("expected one plugin, got %v", plugins)
+	}
+	p := plugins[0]
+	if p.Metadata.Verified != VerifiedChecksum || p.Metadata.SHA256 != Digest([]byte(verifyScript)) || p.Metadata.Version != "1.0.0" {
+		t.Errorf("unexpected metadata %+v", p.Metadata)
+	}
+	if output, err := m.Execute(p, Input{}, 2*time.Second); err != nil || output != "verified" {
+		t.Errorf("Execute = %q, %v", output, err)
+	}
+
+	// Editing the installed file stops it from running
+	os.WriteFile(p.Path, []byte("#!/bin/sh\necho tampered\n"), 0755)
+	if _, err := m.Execute(p, Input{}, 2*time.Second); err == nil || !strings.Contains(err.Error(), "modified after install") {
+		t.Errorf("Execute after tampering = %v, want refused", err)
+	}
+	if err := m.Runnable(p, false); err == nil {
+		t.Error("Runnable should report the modified file")
+	}
+}
+
+func TestCheckIntegrity_PersistsDigests(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prism-plugin-ci.sh")
+	os.WriteFile(path, []byte(verifyScript), 0755)
+	p := Plugin{Name: "ci", Path: path, Metadata: Metadata{SHA256: Digest([]byte(verifyScript))}}
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	os.Mkdir(cacheDir, 0700)
+	cachePath := filepath.Join(cacheDir, "digests.json")
+
+	if err := (&Manager{digests: cache.NewPersistent(cachePath)}).CheckIntegrity(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next process finds the digest without hashing the file
+	info, _ := os.Stat(path)
+	next := &Manager{digests: cache.NewPersistent(cachePath)}
+	if digest, ok := next.digests.Get(digestKey(path, info)); !ok || digest != p.Metadata.SHA256 {
+		t.Errorf("cached digest = %q, %v; want the file's", digest, ok)
+	}
+
+	// A changed file is hashed again
+	os.WriteFile(path, []byte("#!/bin/sh\necho tampered\n"), 0755)
+	if err := next.CheckIntegrity(p); err == nil || !strings.Contains(err.Error(), "modified after install") {
+		t.Errorf("CheckIntegrity after tampering = %v, want refused", err)
+	}
+}
+
+func TestAdd_RejectsBadChecksum(t *testing.T) {
+	srv := newPluginServer(t, map[string]string{
+		"/prism-plugin-ci.sh":        verifyScript,
+		"/prism-plugin-ci.sh.sha256": Digest([]byte("something else")) + "\n",
+	})
+	m := &Manager{pluginDir: t.TempDir()}
+
+	err := m.Add(srv.URL + "/prism-plugin-ci.sh")
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Add error = %v, want a checksum mismatch", err)
+	}
+	if entries, _ := os.ReadDir(m.pluginDir); len(entries) != 0 {
+		t.Errorf("nothing should be installed, found %d files", len(entries))
+	}
+}
+
+func TestAdd_Unverified(t *testing.T) {
+	srv := newPluginServer(t, map[string]string{"/prism-plugin-ci.sh": verifyScript})
+
+	// Allowed by default, with the digest recorded
+	m := &Manager{pluginDir: t.TempDir()}
+	if err := m.Add(srv.URL + "/prism-plugin-ci.sh"); err != nil {
+		t.Fatal(err)
+	}
+	plugins, _ := m.Discover()
+	if len(plugins) != 1 || plugins[0].Metadata.Verified != "" || plugins[0].Metadata.SHA256 == "" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	if err := m.Runnable(plugins[0], true); err == nil {
+		t.Error("Runnable should refuse an unverified plugin when requireVerified is set")
+	}
+
+	// Refused when verification is required
+	strict := &Manager{pluginDir: t.TempDir(), trust: Trust{RequireVerified: true}}
+	if err := strict.Add(srv.URL + "/prism-plugin-ci.sh"); err == nil || !strings.Contains(err.Error(), "requireVerified") {
+		t.Errorf("Add error = %v, want refused", err)
+	}
+}
+
+func TestAdd_Signature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	sums := Digest([]byte(verifyScript)) + "  prism-plugin-ci.sh\n"
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums)))
+
+	files := map[string]string{
+		"/acme/prism-plugin-ci.sh":            verifyScript,
+		"/acme/prism-plugin-ci.sh.sha256":     sums,
+		"/acme/prism-plugin-ci.sh.sha256.sig": sig,
+	}
+	srv := newPluginServer(t, files)
+	publisher := srv.URL + "/acme/"
+
+	m := &Manager{pluginDir: t.TempDir(), trust: Trust{Keys: map[string]string{publisher: publicPEM(t, pub)}}}
+	if err := m.Add(publisher + "prism-plugin-ci.sh"); err != nil {
+		t.Fatal(err)
+	}
+	plugins, _ := m.Discover()
+	if len(plugins) != 1 || plugins[0].Metadata.Verified != VerifiedSignature {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+
+	// A different pinned key refuses the same download
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	m = &Manager{pluginDir: t.TempDir(), trust: Trust{Keys: map[string]string{publisher: publicPEM(t, otherPub)}}}
+	if err := m.Add(publisher + "prism-plugin-ci.sh"); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Add with the wrong key = %v, want a signature error", err)
+	}
+
+	// So does a missing signature
+	delete(files, "/acme/prism-plugin-ci.sh.sha256.sig")
+	m = &Manager{pluginDir: t.TempDir(), trust: Trust{Keys: map[string]string{publisher: publicPEM(t, pub)}}}
+	if err := m.Add(publisher + "prism-plugin-ci.sh"); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Add without a signature = %v, want refused", err)
+	}
+}
+
+func TestVerifyRelease(t *testing.T) {
+	binary := []byte("\x7fELF fake binary")
+	sums := "0000000000000000000000000000000000000000000000000000000000000000  prism-plugin-ci-linux-arm64\n" +
+		Digest(binary) + "  prism-plugin-ci-linux-amd64\n"
+	srv := newPluginServer(t, map[string]string{"/checksums.txt": sums})
+	assets := map[string]string{"checksums.txt": srv.URL + "/checksums.txt"}
+	m := &Manager{}
+
+	v, err := m.verifyRelease(http.DefaultClient, assets, "prism-plugin-ci-linux-amd64", "https://github.com/acme/prism-plugin-ci", binary, nil)
+	if err != nil || v.verified != VerifiedChecksum {
+		t.Errorf("verifyRelease = %+v, %v", v, err)
+	}
+	if _, err := m.verifyRelease(http.DefaultClient, assets, "prism-plugin-ci-linux-arm64", "https://github.com/acme/prism-plugin-ci", binary, nil); err == nil {
+		t.Error("expected a mismatch for the wrong asset's checksum")
+	}
+	if _, err := m.verifyRelease(http.DefaultClient, assets, "prism-plugin-ci-darwin-arm64", "https://github.com/acme/prism-plugin-ci", binary, nil); err == nil {
+		t.Error("expected an error for an asset missing from the checksums")
+	}
+
+	// The manifest is checked against the same checksums...
+	manifest := []byte(`{"name": "ci"}`)
+	withManifest := newPluginServer(t, map[string]string{"/checksums.txt": sums + Digest(manifest) + "  " + ManifestFile + "\n"})
+	manifestAssets := map[string]string{"checksums.txt": withManifest.URL + "/checksums.txt"}
+	if v, err := m.verifyRelease(http.DefaultClient, manifestAssets, "prism-plugin-ci-linux-amd64", "https://github.com/acme/prism-plugin-ci", binary, manifest); err != nil || v.verified != VerifiedChecksum {
+		t.Errorf("verifyRelease with manifest = %+v, %v", v, err)
+	}
+	if _, err := m.verifyRelease(http.DefaultClient, manifestAssets, "prism-plugin-ci-linux-amd64", "https://github.com/acme/prism-plugin-ci", binary, []byte(`{"name": "evil"}`)); err == nil {
+		t.Error("expected a mismatch for a modified manifest")
+	}
+	// ...and without a checksum for it, the install is not verified
+	if v, err := m.verifyRelease(http.DefaultClient, assets, "prism-plugin-ci-linux-amd64", "https://github.com/acme/prism-plugin-ci", binary, manifest); err != nil || v.verified != "" {
+		t.Errorf("verifyRelease with unlisted manifest = %+v, %v; want unverified", v, err)
+	}
+
+	// A key pinned for the GitHub owner requires a signed checksums file
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	m.trust = Trust{Keys: map[string]string{"acme": publicPEM(t, &key.PublicKey)}}
+	if _, err := m.verifyRelease(http.DefaultClient, assets, "prism-plugin-ci-linux-amd64", "https://github.com/acme/prism-plugin-ci", binary, nil); err == nil {
+		t.Error("expected an error for unsigned checksums from a pinned publisher")
+	}
+}
+
+func TestVerification_Weakest(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{VerifiedSignature, VerifiedChecksum, VerifiedChecksum},
+		{VerifiedChecksum, VerifiedSignature, VerifiedChecksum},
+		{VerifiedSignature, VerifiedSignature, VerifiedSignature},
+		{VerifiedChecksum, "", ""},
+		{"", VerifiedSignature, ""},
+	}
+	for _, tt := range tests {
+		got := verification{verified: tt.a}.weakest(verification{verified: tt.b})
+		if got.verified != tt.want {
+			t.Errorf("%q weakest %q = %q, want %q", tt.a, tt.b, got.verified, tt.want)
+		}
+	}
+}
+
+func TestVerifySignature_ECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	message := []byte("abc  prism-plugin-ci\n")
+	digest := sha256.Sum256(message)
+	raw, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	sig := []byte(base64.StdEncoding.EncodeToString(raw) + "\n")
+
+	// The key may be given inline or as a file
+	keyPath := filepath.Join(t.TempDir(), "acme.pub")
+	os.WriteFile(keyPath, []byte(publicPEM(t, &key.PublicKey)), 0644)
+
+	for _, k := range []string{publicPEM(t, &key.PublicKey), keyPath} {
+		if err := VerifySignature(k, message, sig); err != nil {
+			t.Errorf("VerifySignature: %v", err)
+		}
+	}
+	if err := VerifySignature(keyPath, []byte("other"), sig); err == nil {
+		t.Error("expected an invalid signature for a different message")
+	}
+}
+
+func publicPEM(t *testing.T, pub any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
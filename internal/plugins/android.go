@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/format"
 	"github.com/himattm/prism/internal/plugin"
 )
 
@@ -17,6 +18,8 @@ import (
 //     Options: serial, model, version, sdk, manufacturer, device, build, arch
 //     Combine with colons: "model:version", "device:sdk:build"
 //   - packages: array of package names for version lookup (supports wildcards)
+//   - format: text/template for the section
+//     Fields: devices (list of "display [app version]" strings), count
 type AndroidPlugin struct {
 	cache *cache.Cache
 }
@@ -24,6 +27,7 @@ type AndroidPlugin struct {
 type androidConfig struct {
 	Display  string   // What to display: "serial", "model", "version", "model:version"
 	Packages []string // Package names to look up versions
+	Format   string   // Optional text/template for the whole section
 }
 
 func (p *AndroidPlugin) Name() string {
@@ -49,6 +53,9 @@ func (p *AndroidPlugin) Execute(ctx context.Context, input plugin.Input) (string
 
 	// Include display config in cache key so config changes invalidate cache
 	cacheKey := "android:" + cfg.Display
+	if cfg.Format != "" {
+		cacheKey += ":" + cfg.Format
+	}
 
 	// Check cache first
 	if p.cache != nil {
@@ -77,34 +84,48 @@ func (p *AndroidPlugin) Execute(ctx context.Context, input plugin.Input) (string
 		return "", nil
 	}
 
-	// Format output (dim emerald green for Android devices)
+	// Format output (dim device color - emerald green by default)
 	dim := input.Colors["dim"]
-	green := input.Colors["emerald"]
+	deviceColor := input.Colors["device"]
 	gray := input.Colors["gray"]
 	reset := input.Colors["reset"]
 
 	var parts []string
+	var devices []string
 	for _, serial := range serials {
 		// Get display string based on config
 		display := getDeviceDisplay(ctx, serial, cfg.Display)
+		device := display
 
-		// Color the entire device entry uniformly (dim + emerald)
-		deviceStr := dim + green + "⬡ " + display
+		// Color the entire device entry uniformly (dim + device color)
+		deviceStr := dim + deviceColor + "⬡ " + display
 
 		// Look up app version if packages configured
 		if len(cfg.Packages) > 0 {
 			if version := getAppVersion(ctx, serial, cfg.Packages); version != "" {
-				deviceStr += " " + gray + version + green
+				deviceStr += " " + gray + version + deviceColor
+				device += " " + version
 			}
 		}
 
 		deviceStr += reset
 		parts = append(parts, deviceStr)
+		devices = append(devices, device)
 	}
 
 	// No prefix - just the devices (hexagon icon denotes Android)
 	result := strings.Join(parts, " ")
 
+	if cfg.Format != "" {
+		fields := map[string]any{
+			"devices": devices,
+			"count":   len(devices),
+		}
+		if formatted, err := format.Render(cfg.Format, fields, input.Colors); err == nil {
+			result = formatted
+		}
+	}
+
 	// Cache for 5 seconds
 	if p.cache != nil {
 		p.cache.Set(cacheKey, result, 5*cache.ProcessTTL)
@@ -159,6 +180,10 @@ func parseAndroidConfig(cfg map[string]any) androidConfig {
 		}
 	}
 
+	if f, ok := androidCfg["format"].(string); ok {
+		result.Format = f
+	}
+
 	if packages, ok := androidCfg["packages"].([]any); ok {
 		for _, p := range packages {
 			if pkg, ok := p.(string); ok {
@@ -9,10 +9,14 @@ import (
 	"strings"
 
 	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/format"
 	"github.com/himattm/prism/internal/plugin"
 )
 
 // GitPlugin shows git branch and status
+// Config options:
+//   - format: text/template for the section
+//     Fields: branch, dirty ("*", "**", "+"...), ahead, behind
 type GitPlugin struct {
 	cache *cache.Cache
 }
@@ -39,7 +43,13 @@ func (p *GitPlugin) Execute(ctx context.Context, input plugin.Input) (string, er
 		return "", nil
 	}
 
+	formatTmpl := format.Get(input.Config, "git")
+
+	// Include format in cache key so config changes invalidate cache
 	cacheKey := fmt.Sprintf("git:%s", projectDir)
+	if formatTmpl != "" {
+		cacheKey += ":" + formatTmpl
+	}
 
 	// Check cache first
 	if p.cache != nil {
@@ -66,11 +76,11 @@ func (p *GitPlugin) Execute(ctx context.Context, input plugin.Input) (string, er
 	behind, ahead := getUpstreamStatus(ctx, projectDir)
 
 	// Format output
-	yellow := input.Colors["yellow"]
+	branchColor := input.Colors["branch"]
 	reset := input.Colors["reset"]
 
 	var result strings.Builder
-	result.WriteString(yellow)
+	result.WriteString(branchColor)
 	result.WriteString(branch)
 
 	if dirty != "" {
@@ -87,6 +97,18 @@ func (p *GitPlugin) Execute(ctx context.Context, input plugin.Input) (string, er
 	result.WriteString(reset)
 	output := result.String()
 
+	if formatTmpl != "" {
+		fields := map[string]any{
+			"branch": branch,
+			"dirty":  dirty,
+			"ahead":  ahead,
+			"behind": behind,
+		}
+		if formatted, err := format.Render(formatTmpl, fields, input.Colors); err == nil {
+			output = formatted
+		}
+	}
+
 	// Cache for 2 seconds
 	if p.cache != nil {
 		p.cache.Set(cacheKey, output, cache.GitTTL)
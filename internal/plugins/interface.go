@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/logging"
 	"github.com/himattm/prism/internal/plugin"
 )
 
@@ -20,9 +21,18 @@ type Registry struct {
 	cache   *cache.Cache
 }
 
-// NewRegistry creates a new plugin registry with all native plugins
+// NewRegistry creates a new plugin registry with all native plugins.
+// The shared cache is persisted so results survive between status line refreshes.
 func NewRegistry() *Registry {
-	c := cache.New()
+	c := cache.NewPersistent(cache.Path("plugins"))
+	// Never write the OAuth token to disk
+	c.Transient(tokenCacheKey)
+	return NewRegistryWithCache(c)
+}
+
+// NewRegistryWithCache creates a registry whose plugins share c, e.g. a
+// memory-only cache for previews that must not touch the real caches
+func NewRegistryWithCache(c *cache.Cache) *Registry {
 	r := &Registry{
 		plugins: make(map[string]NativePlugin),
 		cache:   c,
@@ -49,6 +59,11 @@ func (r *Registry) Register(p NativePlugin) {
 	r.plugins[p.Name()] = p
 }
 
+// Flush persists cached plugin results for the next prism invocation
+func (r *Registry) Flush() error {
+	return r.cache.Flush()
+}
+
 // Get returns a native plugin by name, or nil if not found
 func (r *Registry) Get(name string) NativePlugin {
 	return r.plugins[name]
@@ -105,13 +120,26 @@ func (r *Registry) GetHookablePlugins() []Hookable {
 	return hookable
 }
 
-// RunHooks executes hooks on all hookable plugins sequentially
+// RunHooks executes hooks on all hookable plugins sequentially.
+// Cache changes made by hooks (e.g. invalidation on idle) are flushed to disk
+// so the next status line render sees them.
 func (r *Registry) RunHooks(ctx context.Context, hookType HookType, hookCtx HookContext) []string {
 	var outputs []string
 	for _, h := range r.GetHookablePlugins() {
-		if output, err := h.OnHook(ctx, hookType, hookCtx); err == nil && output != "" {
+		name := "unknown"
+		if p, ok := h.(NativePlugin); ok {
+			name = p.Name()
+		}
+		output, err := h.OnHook(ctx, hookType, hookCtx)
+		if err != nil {
+			logging.Warn("hook.plugin", "hook", string(hookType), "plugin", name, "error", err)
+			continue
+		}
+		if output != "" {
+			logging.Info("hook.plugin", "hook", string(hookType), "plugin", name, "output", output)
 			outputs = append(outputs, output)
 		}
 	}
+	r.Flush()
 	return outputs
 }
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/logging"
 	"github.com/himattm/prism/internal/plugin"
 )
 
@@ -95,6 +96,7 @@ func (p *UpdatePlugin) Execute(ctx context.Context, input plugin.Input) (string,
 
 	latestVersion, err := fetchLatestVersion(fetchCtx)
 	if err != nil {
+		logging.Warn("update.check", "error", err)
 		// On error, use stale cache if available
 		if cacheExists && cacheData.UpdateAvail {
 			return formatUpdateIndicator(input.Colors), nil
@@ -105,6 +107,7 @@ func (p *UpdatePlugin) Execute(ctx context.Context, input plugin.Input) (string,
 	// Compare versions
 	currentVersion := input.Prism.Version
 	updateAvail := compareVersions(currentVersion, latestVersion) < 0
+	logging.Info("update.check", "current", currentVersion, "latest", latestVersion, "update_available", updateAvail)
 
 	// Save to cache (format compatible with prism-update-hook.sh)
 	saveUpdateCache(updateCache{
@@ -259,12 +262,14 @@ func (p *UpdatePlugin) handleAutoInstall(hookCtx HookContext) (string, error) {
 		}
 	}
 	if !autoInstall {
+		logging.Debug("update.auto", "action", "skip", "reason", "auto_install disabled")
 		return "", nil
 	}
 
 	// Check if update is available from cache
 	cacheData, exists := loadUpdateCache()
 	if !exists || !cacheData.UpdateAvail {
+		logging.Debug("update.auto", "action", "skip", "reason", "no update available", "checked", exists)
 		return "", nil
 	}
 
@@ -273,6 +278,7 @@ func (p *UpdatePlugin) handleAutoInstall(hookCtx HookContext) (string, error) {
 	if data, err := os.ReadFile(markerFile); err == nil {
 		installedVersion := strings.TrimSpace(string(data))
 		if installedVersion == cacheData.RemoteVersion {
+			logging.Debug("update.auto", "action", "skip", "reason", "already installed", "version", installedVersion)
 			return "", nil // Already installed this version
 		}
 		// Different version - remove stale marker
@@ -285,21 +291,25 @@ func (p *UpdatePlugin) handleAutoInstall(hookCtx HookContext) (string, error) {
 		// Lock exists - check if it's stale (older than 2 minutes)
 		if info, err := os.Stat(lockFile); err == nil {
 			if time.Since(info.ModTime()) < 2*time.Minute {
+				logging.Debug("update.auto", "action", "skip", "reason", "another update is running", "lock", lockFile)
 				return "", nil // Another instance is updating
 			}
 			// Stale lock, remove it
+			logging.Warn("update.auto", "action", "remove stale lock", "lock", lockFile, "age", time.Since(info.ModTime()))
 			os.Remove(lockFile)
 		}
 	}
 
 	// Create lock file
 	if err := os.WriteFile(lockFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0644); err != nil {
+		logging.Error("update.auto", "action", "lock", "error", err)
 		return "", nil
 	}
 
 	// Get path to prism binary
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
+		logging.Error("update.auto", "action", "find binary", "error", err)
 		os.Remove(lockFile)
 		return "", nil
 	}
@@ -309,10 +319,12 @@ func (p *UpdatePlugin) handleAutoInstall(hookCtx HookContext) (string, error) {
 	// (Claude Code keeps the binary open, which can interfere with spawned processes)
 	tempBinary := filepath.Join(os.TempDir(), "prism-updater")
 	if err := copyFile(prismPath, tempBinary); err != nil {
+		logging.Error("update.auto", "action", "copy binary", "from", prismPath, "to", tempBinary, "error", err)
 		os.Remove(lockFile)
 		return "", nil
 	}
 	if err := os.Chmod(tempBinary, 0755); err != nil {
+		logging.Error("update.auto", "action", "chmod", "path", tempBinary, "error", err)
 		os.Remove(lockFile)
 		return "", nil
 	}
@@ -329,9 +341,11 @@ func (p *UpdatePlugin) handleAutoInstall(hookCtx HookContext) (string, error) {
 		Setpgid: true,
 	}
 	if err := cmd.Start(); err != nil {
+		logging.Error("update.auto", "action", "spawn updater", "path", tempBinary, "error", err)
 		os.Remove(lockFile)
 		return "", nil
 	}
+	logging.Info("update.auto", "action", "spawn updater", "from", cacheData.LocalVersion, "to", cacheData.RemoteVersion, "updater_pid", cmd.Process.Pid)
 
 	// Return notification that update is starting
 	cyan := "\033[36m"
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/format"
 	"github.com/himattm/prism/internal/plugin"
 )
 
@@ -82,33 +84,58 @@ func (p *UsagePlugin) parseConfig(input plugin.Input) usageConfig {
 }
 
 func (p *UsagePlugin) Execute(ctx context.Context, input plugin.Input) (string, error) {
-	// Check if another usage plugin already rendered this cycle
-	if _, ok := p.cache.Get(usageRenderedKey); ok {
-		return "", nil
-	}
-
 	cfg := p.parseConfig(input)
 
-	// Try to detect if user has OAuth credentials (Max/Pro plan)
-	hasOAuth := p.hasOAuthCredentials()
-
-	var result string
-	var err error
-
-	if hasOAuth {
-		// Max/Pro user - show usage limits
-		result, err = p.renderUsageLimits(ctx, input, cfg)
-	} else {
-		// API billing user - show cost
-		result = p.renderCost(input, cfg)
+	// Max/Pro users (OAuth credentials) see their usage limits; API billing
+	// users, and Max/Pro users until the limits are known, see the cost
+	var usage *UsageResponse
+	if p.hasOAuthCredentials() {
+		usage, _ = p.getUsageData(ctx, input.Prism.IsIdle)
 	}
+	output := p.renderUsageLimits(input, usage, cfg)
 
-	// Mark that we rendered, so other usage plugins skip
-	if result != "" {
-		p.cache.Set(usageRenderedKey, "1", usageRenderedTTL)
+	if tmpl := format.Get(input.Config, "usage"); tmpl != "" {
+		if formatted, err := format.Render(tmpl, usageFields(input, usage), input.Colors); err == nil {
+			output = formatted
+		}
 	}
+	return output, nil
+}
 
-	return result, err
+// usageFields are the fields of a usage format template. Limits that are
+// unknown or absent (API billing) are -1.
+func usageFields(input plugin.Input, usage *UsageResponse) map[string]any {
+	fields := map[string]any{
+		"plan":        usage != nil,
+		"cost":        input.Session.CostUSD,
+		"hours":       -1,
+		"days":        -1,
+		"opus":        -1,
+		"hours_reset": "",
+		"days_reset":  "",
+		"opus_reset":  "",
+	}
+	if usage == nil {
+		return fields
+	}
+	limits := []struct {
+		name  string
+		limit *UsageLimit
+		days  bool
+	}{
+		{"hours", usage.FiveHour, false},
+		{"days", usage.SevenDay, true},
+		{"opus", usage.SevenDayOpus, true},
+	}
+	for _, l := range limits {
+		if l.limit == nil {
+			continue
+		}
+		fields[l.name] = int(math.Round(l.limit.Utilization))
+		remaining, _ := TimeUntilReset(l.limit.ResetsAt)
+		fields[l.name+"_reset"] = FormatTimeRemaining(remaining, l.days)
+	}
+	return fields
 }
 
 // hasOAuthCredentials checks if OAuth credentials exist
@@ -133,6 +160,21 @@ func (p *UsagePlugin) hasOAuthCredentials() bool {
 	return hasToken
 }
 
+// PresetUsage makes the usage plugins sharing c render a fixed plan instead
+// of detecting it: plan limits from usage, or the session cost (API billing)
+// when usage is nil. Previews use this with a memory-only cache.
+func PresetUsage(c *cache.Cache, usage *UsageResponse) {
+	const ttl = 24 * time.Hour
+	if usage == nil {
+		c.Set("has_oauth", "false", ttl)
+		return
+	}
+	c.Set("has_oauth", "true", ttl)
+	if data, err := json.Marshal(usage); err == nil {
+		c.Set(usageCacheKey, string(data), ttl)
+	}
+}
+
 // renderCost renders the cost for API billing users
 func (p *UsagePlugin) renderCost(input plugin.Input, cfg usageConfig) string {
 	cost := input.Session.CostUSD
@@ -145,26 +187,24 @@ func (p *UsagePlugin) renderCost(input plugin.Input, cfg usageConfig) string {
 	return fmt.Sprintf(format, color, cost, reset)
 }
 
-// renderUsageLimits renders usage limits for Max/Pro users
-func (p *UsagePlugin) renderUsageLimits(ctx context.Context, input plugin.Input, cfg usageConfig) (string, error) {
-	// Get usage data (with caching)
-	usage, err := p.getUsageData(ctx, input.Prism.IsIdle)
-	if err != nil || usage == nil {
-		// Fall back to cost if we can't get usage data
-		return p.renderCost(input, cfg), nil
+// renderUsageLimits renders usage limits for Max/Pro users, or the cost
+// when there are none
+func (p *UsagePlugin) renderUsageLimits(input plugin.Input, usage *UsageResponse, cfg usageConfig) string {
+	if usage == nil {
+		return p.renderCost(input, cfg)
 	}
 
 	if cfg.style == "bars" {
-		return p.renderBars(input, usage, cfg), nil
+		return p.renderBars(input, usage, cfg)
 	}
-	return p.renderText(input, usage, cfg), nil
+	return p.renderText(input, usage, cfg)
 }
 
 // renderText renders usage as text with countdown labels
 func (p *UsagePlugin) renderText(input plugin.Input, usage *UsageResponse, cfg usageConfig) string {
 	white := input.Colors["white"]
-	yellow := input.Colors["yellow"]
-	red := input.Colors["red"]
+	yellow := input.Colors["warning"]
+	red := input.Colors["critical"]
 	reset := input.Colors["reset"]
 
 	var result string
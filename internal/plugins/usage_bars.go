@@ -24,11 +24,6 @@ func (p *UsageBarsPlugin) SetCache(c *cache.Cache) {
 }
 
 func (p *UsageBarsPlugin) Execute(ctx context.Context, input plugin.Input) (string, error) {
-	// Check if another usage plugin already rendered this cycle
-	if _, ok := p.cache.Get(usageRenderedKey); ok {
-		return "", nil
-	}
-
 	// Check if enabled (default: true)
 	showHours := true
 	showDays := true
@@ -105,11 +100,6 @@ func (p *UsageBarsPlugin) Execute(ctx context.Context, input plugin.Input) (stri
 			peach, LevelToBarChar(usageLevel), reset)
 	}
 
-	// Mark that we rendered, so other usage plugins skip
-	if result != "" {
-		p.cache.Set(usageRenderedKey, "1", usageRenderedTTL)
-	}
-
 	return result, nil
 }
 
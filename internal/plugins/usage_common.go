@@ -20,13 +20,21 @@ const (
 	tokenCacheKey   = "oauth_token"
 	tokenCacheTTL   = 5 * time.Minute
 	usageAPITimeout = 3 * time.Second
-
-	// usageRenderedKey is used to coordinate between usage plugins
-	// to prevent duplicate rendering in the same status line refresh
-	usageRenderedKey = "usage_rendered"
-	usageRenderedTTL = 100 * time.Millisecond
 )
 
+// usageSections show the same plan usage in different styles
+var usageSections = map[string]bool{
+	"usage":      true,
+	"usage_bars": true,
+	"usage_text": true,
+}
+
+// IsUsageSection reports whether name is one of the usage sections, of
+// which a status line shows only the first with output
+func IsUsageSection(name string) bool {
+	return usageSections[name]
+}
+
 // UsageResponse represents the API response from the usage endpoint
 type UsageResponse struct {
 	FiveHour     *UsageLimit `json:"five_hour"`
@@ -383,6 +383,52 @@ func TestUsagePlugin_Execute_APIBilling(t *testing.T) {
 	}
 }
 
+func TestUsagePlugin_Execute_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		usage    *UsageResponse
+		format   string
+		expected string
+	}{
+		{
+			name:     "api billing",
+			format:   `{{if .plan}}plan{{else}}${{printf "%.1f" .cost}}{{end}}`,
+			expected: "$2.5",
+		},
+		{
+			name: "plan limits",
+			usage: &UsageResponse{
+				FiveHour: &UsageLimit{Utilization: 41.6},
+				SevenDay: &UsageLimit{Utilization: 78},
+			},
+			format:   `5h {{.hours}}% 7d {{.days}}%{{if ge .opus 0}} opus {{.opus}}%{{end}}`,
+			expected: "5h 42% 7d 78%",
+		},
+		{
+			name:     "invalid template falls back",
+			format:   `{{.nope}}`,
+			expected: "$2.50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &UsagePlugin{}
+			p.SetCache(cache.New())
+			PresetUsage(p.cache, tt.usage)
+
+			input := plugin.Input{
+				Session: plugin.SessionContext{CostUSD: 2.50},
+				Config:  map[string]any{"usage": map[string]any{"format": tt.format}},
+			}
+			result, err := p.Execute(context.Background(), input)
+			if err != nil || result != tt.expected {
+				t.Errorf("Execute = %q, %v; want %q", result, err, tt.expected)
+			}
+		})
+	}
+}
+
 func TestTimeUntilReset(t *testing.T) {
 	// Test with a future time
 	futureTime := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
@@ -23,11 +23,6 @@ func (p *UsageTextPlugin) SetCache(c *cache.Cache) {
 }
 
 func (p *UsageTextPlugin) Execute(ctx context.Context, input plugin.Input) (string, error) {
-	// Check if another usage plugin already rendered this cycle
-	if _, ok := p.cache.Get(usageRenderedKey); ok {
-		return "", nil
-	}
-
 	// Check if enabled (default: true)
 	showHours := true
 	showDays := true
@@ -56,8 +51,8 @@ func (p *UsageTextPlugin) Execute(ctx context.Context, input plugin.Input) (stri
 
 	// Get colors for urgency levels
 	white := input.Colors["white"]
-	yellow := input.Colors["yellow"]
-	red := input.Colors["red"]
+	yellow := input.Colors["warning"]
+	red := input.Colors["critical"]
 	reset := input.Colors["reset"]
 
 	var result string
@@ -98,11 +93,6 @@ func (p *UsageTextPlugin) Execute(ctx context.Context, input plugin.Input) (stri
 			color, timeStr, usage.SevenDayOpus.Utilization, reset)
 	}
 
-	// Mark that we rendered, so other usage plugins skip
-	if result != "" {
-		p.cache.Set(usageRenderedKey, "1", usageRenderedTTL)
-	}
-
 	return result, nil
 }
 
@@ -0,0 +1,259 @@
+// Package project detects what kind of project a directory holds, so
+// `prism init` can propose sections and plugin options that fit it
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/himattm/prism/internal/config"
+)
+
+// Kind is a detected project type
+type Kind string
+
+const (
+	Android     Kind = "android"
+	IOS         Kind = "ios"
+	Flutter     Kind = "flutter"
+	ReactNative Kind = "react-native"
+	Go          Kind = "go"
+	Node        Kind = "node"
+)
+
+// Names are shown to the user, e.g. "Detected: Flutter, Android"
+var names = map[Kind]string{
+	Android:     "Android (Gradle)",
+	IOS:         "iOS (Xcode)",
+	Flutter:     "Flutter",
+	ReactNative: "React Native",
+	Go:          "Go",
+	Node:        "Node",
+}
+
+// icons are proposed for the dir section, by the first detected kind
+var icons = map[Kind]string{
+	Android:     "🤖",
+	IOS:         "🍎",
+	Flutter:     "🐦",
+	ReactNative: "⚛️",
+	Go:          "🐹",
+	Node:        "📦",
+}
+
+// Info describes a detected project
+type Info struct {
+	Kinds          []Kind   // Most specific first: cross-platform frameworks before the platforms they contain
+	ApplicationIDs []string // Android applicationIds (with applicationIdSuffix variants)
+}
+
+// Has reports whether kind was detected
+func (i Info) Has(kind Kind) bool {
+	for _, k := range i.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the display names of the detected kinds
+func (i Info) Names() []string {
+	out := make([]string, len(i.Kinds))
+	for n, k := range i.Kinds {
+		out[n] = names[k]
+	}
+	return out
+}
+
+// Detect inspects dir (without recursing into the whole tree) and reports
+// the project kinds it finds
+func Detect(dir string) Info {
+	var info Info
+
+	if data, err := os.ReadFile(filepath.Join(dir, "pubspec.yaml")); err == nil && isFlutterPubspec(string(data)) {
+		info.Kinds = append(info.Kinds, Flutter)
+	}
+
+	pkg, hasPackageJSON := readPackageJSON(filepath.Join(dir, "package.json"))
+	if hasPackageJSON && pkg.depends("react-native") {
+		info.Kinds = append(info.Kinds, ReactNative)
+	}
+
+	// Native Android and iOS projects, alone or inside android/ and ios/.
+	// Gradle builds plain JVM projects too, so Android needs a marker.
+	var gradleFiles []string
+	android := false
+	for _, sub := range []string{".", "app", "android", filepath.Join("android", "app")} {
+		for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+			path := filepath.Join(dir, sub, name)
+			if data, err := os.ReadFile(path); err == nil {
+				gradleFiles = append(gradleFiles, path)
+				android = android || strings.Contains(string(data), "com.android.")
+			}
+		}
+		android = android || exists(filepath.Join(dir, sub, "src", "main", "AndroidManifest.xml"))
+	}
+	if android {
+		info.Kinds = append(info.Kinds, Android)
+		info.ApplicationIDs = applicationIDs(gradleFiles)
+	}
+
+	if hasXcodeProject(dir) || hasXcodeProject(filepath.Join(dir, "ios")) {
+		info.Kinds = append(info.Kinds, IOS)
+	}
+
+	if exists(filepath.Join(dir, "go.mod")) {
+		info.Kinds = append(info.Kinds, Go)
+	}
+
+	if hasPackageJSON && !info.Has(ReactNative) {
+		info.Kinds = append(info.Kinds, Node)
+	}
+
+	return info
+}
+
+// Suggest proposes a project config for the detected kinds: lines changed
+// for any code project, and Android devices for Android apps. A Flutter or
+// React Native app with both halves also gets an "ios" profile that hides
+// the devices while working in ios/.
+func Suggest(info Info) config.Config {
+	cfg := config.Config{Icon: "💎"}
+	if len(info.Kinds) > 0 {
+		cfg.Icon = icons[info.Kinds[0]]
+	}
+
+	sections := []string{"dir", "model", "context", "usage", "git"}
+	if len(info.Kinds) > 0 {
+		sections = []string{"dir", "model", "context", "linesChanged", "usage", "git"}
+	}
+	if info.Has(Android) {
+		sections = append(sections, "android_devices")
+		options := map[string]any{"display": "model"}
+		if len(info.ApplicationIDs) > 0 {
+			packages := make([]any, len(info.ApplicationIDs))
+			for i, id := range info.ApplicationIDs {
+				packages[i] = id
+			}
+			options["packages"] = packages
+		}
+		cfg.Plugins = map[string]any{"android_devices": options}
+	}
+	if (info.Has(Flutter) || info.Has(ReactNative)) && info.Has(Android) && info.Has(IOS) {
+		cfg.Profiles = map[string]config.Profile{"ios": {Match: &config.ProfileMatch{Path: "ios/**"}}}
+	}
+	SetSections(&cfg, sections)
+
+	return cfg
+}
+
+// SetSections sets the sections of a suggested config, and of its "ios"
+// profile without the Android devices
+func SetSections(cfg *config.Config, sections []string) {
+	all := make([]any, 0, len(sections))
+	var ios []any
+	for _, name := range sections {
+		all = append(all, name)
+		if name != "android_devices" {
+			ios = append(ios, name)
+		}
+	}
+	cfg.Sections = all
+
+	if profile, ok := cfg.Profiles["ios"]; ok {
+		profile.Sections = ios
+		cfg.Profiles["ios"] = profile
+	}
+}
+
+var (
+	applicationIDPattern = regexp.MustCompile(`applicationId\s*(?:=|\()?\s*["']([\w.]+)["']`)
+	suffixPattern        = regexp.MustCompile(`applicationIdSuffix\s*(?:=|\()?\s*["']([\w.]+)["']`)
+)
+
+// applicationIDs collects applicationId values from Gradle build files, plus
+// each one with every applicationIdSuffix (e.g. com.example.app.debug)
+func applicationIDs(files []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var base []string
+		for _, m := range applicationIDPattern.FindAllStringSubmatch(string(data), -1) {
+			base = append(base, m[1])
+			add(m[1])
+		}
+		for _, m := range suffixPattern.FindAllStringSubmatch(string(data), -1) {
+			suffix := m[1]
+			if !strings.HasPrefix(suffix, ".") {
+				suffix = "." + suffix
+			}
+			for _, id := range base {
+				add(id + suffix)
+			}
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func isFlutterPubspec(data string) bool {
+	for _, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "sdk: flutter" || strings.HasPrefix(line, "flutter:") {
+			return true
+		}
+	}
+	return false
+}
+
+type packageJSON struct {
+	Dependencies    map[string]any `json:"dependencies"`
+	DevDependencies map[string]any `json:"devDependencies"`
+}
+
+func (p packageJSON) depends(name string) bool {
+	_, dep := p.Dependencies[name]
+	_, dev := p.DevDependencies[name]
+	return dep || dev
+}
+
+func readPackageJSON(path string) (packageJSON, bool) {
+	var pkg packageJSON
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pkg, false
+	}
+	json.Unmarshal(data, &pkg)
+	return pkg, true
+}
+
+func hasXcodeProject(dir string) bool {
+	for _, pattern := range []string{"*.xcodeproj", "*.xcworkspace"} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
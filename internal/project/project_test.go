@@ -0,0 +1,160 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		kinds []Kind
+		ids   []string
+	}{
+		{
+			name: "android kotlin dsl",
+			files: map[string]string{
+				"settings.gradle.kts": "",
+				"app/build.gradle.kts": `plugins { id("com.android.application") }
+android {
+    defaultConfig { applicationId = "com.example.shop" }
+    buildTypes { debug { applicationIdSuffix = ".debug" } }
+}`,
+			},
+			kinds: []Kind{Android},
+			ids:   []string{"com.example.shop", "com.example.shop.debug"},
+		},
+		{
+			name: "android groovy",
+			files: map[string]string{
+				"app/build.gradle":                 `defaultConfig { applicationId 'com.example.legacy' }`,
+				"app/src/main/AndroidManifest.xml": "<manifest/>",
+			},
+			kinds: []Kind{Android},
+			ids:   []string{"com.example.legacy"},
+		},
+		{
+			name: "kotlin server",
+			files: map[string]string{
+				"settings.gradle.kts": "",
+				"build.gradle.kts":    `plugins { kotlin("jvm") version "2.0.0"; application }`,
+			},
+		},
+		{
+			name: "flutter",
+			files: map[string]string{
+				"pubspec.yaml":               "name: app\ndependencies:\n  flutter:\n    sdk: flutter\n",
+				"android/app/build.gradle":   `apply plugin: "com.android.application"` + "\n" + `applicationId "com.example.flutter"`,
+				"ios/Runner.xcodeproj/x.pbx": "",
+			},
+			kinds: []Kind{Flutter, Android, IOS},
+			ids:   []string{"com.example.flutter"},
+		},
+		{
+			name: "react native",
+			files: map[string]string{
+				"package.json":             `{"dependencies": {"react-native": "0.74.0"}}`,
+				"android/app/build.gradle": `apply plugin: "com.android.application"` + "\n" + `applicationId "com.example.rn"`,
+			},
+			kinds: []Kind{ReactNative, Android},
+			ids:   []string{"com.example.rn"},
+		},
+		{
+			name:  "xcode",
+			files: map[string]string{"App.xcworkspace/contents.xcworkspacedata": ""},
+			kinds: []Kind{IOS},
+		},
+		{
+			name:  "go and node",
+			files: map[string]string{"go.mod": "module x", "package.json": `{"devDependencies": {"vite": "5"}}`},
+			kinds: []Kind{Go, Node},
+		},
+		{
+			name:  "unknown",
+			files: map[string]string{"README.md": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Detect(writeFiles(t, tt.files))
+			if !reflect.DeepEqual(info.Kinds, tt.kinds) {
+				t.Errorf("kinds = %v, want %v", info.Kinds, tt.kinds)
+			}
+			if !reflect.DeepEqual(info.ApplicationIDs, tt.ids) {
+				t.Errorf("application ids = %v, want %v", info.ApplicationIDs, tt.ids)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := Suggest(Info{Kinds: []Kind{Flutter, Android}, ApplicationIDs: []string{"com.example"}})
+
+	if cfg.Icon != icons[Flutter] {
+		t.Errorf("expected the Flutter icon, got %q", cfg.Icon)
+	}
+	sections := cfg.GetSections()
+	if last := sections[len(sections)-1].Name; last != "android_devices" {
+		t.Errorf("expected android_devices last, got %+v", sections)
+	}
+	packages := cfg.LoadPluginConfig("android_devices")["packages"]
+	if !reflect.DeepEqual(packages, []any{"com.example"}) {
+		t.Errorf("unexpected packages %v", packages)
+	}
+
+	if cfg := Suggest(Info{}); cfg.Icon != "💎" || cfg.Plugins != nil {
+		t.Errorf("unexpected default suggestion %+v", cfg)
+	}
+}
+
+func TestSuggest_CrossPlatform(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	names := func(sections any) string {
+		var out []string
+		for _, s := range sections.([]any) {
+			out = append(out, s.(string))
+		}
+		return strings.Join(out, ",")
+	}
+
+	cfg := Suggest(Info{Kinds: []Kind{ReactNative, Android, IOS}})
+	if got := names(cfg.Sections); got != "dir,model,context,linesChanged,usage,git,android_devices" {
+		t.Errorf("sections = %s", got)
+	}
+	ios, ok := cfg.Profiles["ios"]
+	if !ok || ios.Match == nil || ios.Match.Path != "ios/**" {
+		t.Fatalf("expected an ios profile for ios/, got %+v", cfg.Profiles)
+	}
+	if got := names(ios.Sections); got != "dir,model,context,linesChanged,usage,git" {
+		t.Errorf("ios profile sections = %s, want them without android_devices", got)
+	}
+
+	// Edited sections keep the profile in step
+	SetSections(&cfg, []string{"dir", "android_devices", "git"})
+	if got := names(cfg.Profiles["ios"].Sections); got != "dir,git" {
+		t.Errorf("ios profile sections after edit = %s", got)
+	}
+
+	if cfg := Suggest(Info{Kinds: []Kind{IOS}}); cfg.Icon != icons[IOS] || cfg.Profiles != nil || !strings.Contains(names(cfg.Sections), "linesChanged") {
+		t.Errorf("unexpected iOS suggestion %+v", cfg)
+	}
+}
@@ -0,0 +1,67 @@
+package statusline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/expr"
+	"github.com/himattm/prism/internal/version"
+)
+
+// visibleSections returns the names of sections whose "when" condition holds.
+// A condition that fails to parse or evaluate hides its section.
+func (sl *StatusLine) visibleSections(sections []config.Section) []string {
+	var names []string
+	var env *expr.Env
+
+	for _, section := range sections {
+		if section.When != "" {
+			if env == nil {
+				e := sl.conditionEnv()
+				env = &e
+			}
+			ok, err := expr.Eval(section.When, *env)
+			if err != nil || !ok {
+				sl.record(section.Name, func(s *SectionStat) {
+					s.Hidden = fmt.Sprintf("hidden by when %q", section.When)
+					s.Err = err
+				})
+				continue
+			}
+		}
+		names = append(names, section.Name)
+	}
+
+	return names
+}
+
+// conditionEnv exposes the session fields plugins receive (plugin.SessionContext
+// and plugin.PrismContext) to "when" expressions
+func (sl *StatusLine) conditionEnv() expr.Env {
+	projectDir := sl.input.Workspace.ProjectDir
+
+	_, err := os.Stat(filepath.Join(projectDir, ".git"))
+	isGitRepo := projectDir != "" && err == nil
+
+	return expr.Env{
+		Vars: map[string]any{
+			"model":         sl.input.Model.DisplayName,
+			"context_pct":   sl.contextPct(),
+			"cost_usd":      sl.input.Cost.TotalCostUSD,
+			"lines_added":   sl.input.Cost.TotalLinesAdded,
+			"lines_removed": sl.input.Cost.TotalLinesRemoved,
+			"session_id":    sl.input.SessionID,
+			"is_idle":       sl.isIdle,
+			"project":       filepath.Base(projectDir),
+			"project_dir":   projectDir,
+			"current_dir":   sl.input.Workspace.CurrentDir,
+			"is_git_repo":   isGitRepo,
+			"is_worktree":   isGitRepo && sl.isWorktree(),
+			"version":       version.Version,
+		},
+		ProjectDir: projectDir,
+		CurrentDir: sl.input.Workspace.CurrentDir,
+	}
+}
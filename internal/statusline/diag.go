@@ -0,0 +1,114 @@
+package statusline
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SectionStat records how one section rendered, so a missing section can be
+// explained (see `prism render --debug`)
+type SectionStat struct {
+	Name      string
+	Kind      string        // "builtin", "native" or "script" ("" when nothing provides the section)
+	Duration  time.Duration // Until the section's value was used, at most the render deadline
+	Refresh   time.Duration // Total plugin run time, longer than Duration when it timed out
+	Cache     string        // Last good value for a slow or failing plugin: "hit" (shown stale) or "miss"
+	TimedOut  bool
+	Err       error
+	Hidden    string // Why the section is not shown, e.g. "empty output" or "dropped to fit width"
+	Compacted bool   // Shortened to fit the line width
+}
+
+// Result summarizes the stat for display
+func (s SectionStat) Result() string {
+	var parts []string
+	switch {
+	case s.TimedOut:
+		parts = append(parts, "timeout")
+	case s.Err != nil:
+		parts = append(parts, "error")
+	case s.Hidden == "":
+		parts = append(parts, "ok")
+	}
+	if s.Hidden != "" {
+		parts = append(parts, s.Hidden)
+	}
+	if s.Compacted && s.Hidden == "" {
+		parts = append(parts, "compacted to fit width")
+	}
+	if s.Cache == "hit" {
+		parts = append(parts, "stale value shown")
+	}
+	if s.TimedOut && s.Refresh > 0 {
+		parts = append(parts, fmt.Sprintf("finished after %s", formatDuration(s.Refresh)))
+	}
+	if s.Err != nil {
+		parts = append(parts, s.Err.Error())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Stats returns what was recorded about each section, in the order they
+// were first seen (sections hidden by "when" come first).
+// Plugins that outlived the render have their final result after Wait.
+func (sl *StatusLine) Stats() []SectionStat {
+	sl.statsMu.Lock()
+	defer sl.statsMu.Unlock()
+	out := make([]SectionStat, len(sl.stats))
+	for i, s := range sl.stats {
+		out[i] = *s
+	}
+	return out
+}
+
+// record updates the stat of a section, adding it on first use
+func (sl *StatusLine) record(name string, update func(*SectionStat)) {
+	sl.statsMu.Lock()
+	defer sl.statsMu.Unlock()
+	for _, s := range sl.stats {
+		if s.Name == name {
+			update(s)
+			return
+		}
+	}
+	s := &SectionStat{Name: name}
+	update(s)
+	sl.stats = append(sl.stats, s)
+}
+
+// WriteStats prints stats as a table
+func WriteStats(w io.Writer, stats []SectionStat) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECTION\tSOURCE\tTIME\tCACHE\tRESULT")
+	for _, s := range stats {
+		kind := s.Kind
+		if kind == "" {
+			kind = "-"
+		}
+		duration := "-"
+		if s.Duration > 0 {
+			duration = formatDuration(s.Duration)
+		}
+		cacheResult := s.Cache
+		if cacheResult == "" {
+			cacheResult = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Name, kind, duration, cacheResult, s.Result())
+	}
+	tw.Flush()
+}
+
+// formatDuration rounds d for display: 0.04ms, 12ms, 1.2s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(100 * time.Millisecond).String()
+	}
+}
@@ -0,0 +1,118 @@
+package statusline
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/himattm/prism/internal/colors"
+)
+
+// compactWidth is the widest a section may be in its compact variant
+const compactWidth = 20
+
+// availableWidth returns the line width to fit into, or 0 for unlimited
+func (sl *StatusLine) availableWidth() int {
+	if sl.config.Width > 0 {
+		return sl.config.Width
+	}
+	if cols, err := strconv.Atoi(sl.getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 0
+}
+
+// compactSection returns a shorter variant of a rendered section, used
+// before dropping sections when a line is too wide
+func (sl *StatusLine) compactSection(name, output string) string {
+	switch name {
+	case "dir":
+		return colors.Wrap(sl.color("dir"), filepath.Base(sl.input.Workspace.ProjectDir))
+	case "model":
+		// "Opus 4.5" -> "Opus"
+		fields := strings.Fields(sl.input.Model.DisplayName)
+		if len(fields) == 0 {
+			return output
+		}
+		return colors.Wrap(sl.color("model"), fields[0])
+	case "context":
+		pct := sl.contextPct()
+		text := fmt.Sprintf("%d%%", pct)
+		if state := contextState(pct); state != "ok" {
+			return colors.Wrap(sl.color(state), text)
+		}
+		return text
+	default:
+		return colors.Truncate(output, compactWidth)
+	}
+}
+
+// layoutLine joins a line's segments, fitting them into the available width:
+// sections first switch to their compact variant, then are dropped, both in
+// drop order. The last remaining section is always kept; it is never the
+// update indicator, which goes first.
+func (sl *StatusLine) layoutLine(segments []segment) string {
+	line := sl.joinSegments(segments)
+	width := sl.availableWidth()
+	if width <= 0 || colors.Width(line) <= width {
+		return line
+	}
+
+	order := dropOrder(segments, sl.config.DropOrder)
+
+	for _, idx := range order {
+		if segments[idx].compact == "" || segments[idx].compact == segments[idx].output {
+			continue
+		}
+		segments[idx].output = segments[idx].compact
+		sl.record(segments[idx].name, func(s *SectionStat) { s.Compacted = true })
+		line = sl.joinSegments(segments)
+		if colors.Width(line) <= width {
+			return line
+		}
+	}
+
+	dropped := make(map[int]bool)
+	for _, idx := range order[:len(order)-1] {
+		dropped[idx] = true
+		sl.record(segments[idx].name, func(s *SectionStat) { s.Hidden = "dropped to fit width" })
+		var kept []segment
+		for i, seg := range segments {
+			if !dropped[i] {
+				kept = append(kept, seg)
+			}
+		}
+		line = sl.joinSegments(kept)
+		if colors.Width(line) <= width {
+			return line
+		}
+	}
+
+	return line
+}
+
+// dropOrder returns segment indices from least to most important: the update
+// indicator, sections named in the dropOrder config (in that order), then the
+// rest right to left
+func dropOrder(segments []segment, configured []string) []int {
+	var order []int
+	seen := make(map[int]bool)
+
+	for _, name := range append([]string{"update"}, configured...) {
+		for i, seg := range segments {
+			if seg.name == name && !seen[i] {
+				order = append(order, i)
+				seen[i] = true
+			}
+		}
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !seen[i] {
+			order = append(order, i)
+		}
+	}
+
+	return order
+}
@@ -0,0 +1,134 @@
+package statusline
+
+import (
+	"strings"
+
+	"github.com/himattm/prism/internal/colors"
+	"github.com/himattm/prism/internal/config"
+)
+
+const (
+	powerlineArrow      = "\uE0B0" // Needs a Powerline or Nerd Font
+	powerlineASCIIArrow = ">"
+)
+
+// powerlineTheme maps sections to segment colors (names from colors.ColorMap).
+// Sections without an entry cycle through the rotation.
+type powerlineTheme struct {
+	sections map[string]config.SegmentStyle
+	rotation []config.SegmentStyle
+}
+
+var powerlineThemes = map[string]powerlineTheme{
+	"default": {
+		sections: map[string]config.SegmentStyle{
+			"update":          {FG: "black", BG: "gold"},
+			"dir":             {FG: "black", BG: "dodger_blue"},
+			"model":           {FG: "white", BG: "purple"},
+			"context":         {FG: "black", BG: "light_gray"},
+			"linesChanged":    {FG: "black", BG: "khaki"},
+			"usage":           {FG: "white", BG: "dim_gray"},
+			"git":             {FG: "black", BG: "orange"},
+			"android_devices": {FG: "black", BG: "emerald"},
+		},
+		rotation: []config.SegmentStyle{
+			{FG: "black", BG: "turquoise"},
+			{FG: "white", BG: "slate_blue"},
+			{FG: "black", BG: "pink"},
+		},
+	},
+	"pastel": {
+		sections: map[string]config.SegmentStyle{
+			"update":          {FG: "black", BG: "peach"},
+			"dir":             {FG: "black", BG: "light_blue"},
+			"model":           {FG: "black", BG: "lavender"},
+			"context":         {FG: "black", BG: "light_cyan"},
+			"linesChanged":    {FG: "black", BG: "light_yellow"},
+			"usage":           {FG: "black", BG: "plum"},
+			"git":             {FG: "black", BG: "light_orange"},
+			"android_devices": {FG: "black", BG: "pale_green"},
+		},
+		rotation: []config.SegmentStyle{
+			{FG: "black", BG: "light_pink"},
+			{FG: "black", BG: "mint"},
+		},
+	},
+	"mono": {
+		rotation: []config.SegmentStyle{
+			{FG: "white", BG: "dark_gray"},
+			{FG: "black", BG: "light_gray"},
+		},
+	},
+}
+
+// segment is one rendered section of a line
+type segment struct {
+	name    string
+	output  string
+	compact string // Shorter variant used when the line is too wide
+}
+
+// joinSegments joins rendered sections with the configured separator style
+func (sl *StatusLine) joinSegments(segments []segment) string {
+	if sl.config.IsPowerline() {
+		return renderPowerline(segments, sl.config.Powerline, sl.colors())
+	}
+
+	parts := make([]string, len(segments))
+	for i, seg := range segments {
+		parts[i] = seg.output
+	}
+	return strings.Join(parts, colors.Separator())
+}
+
+// renderPowerline draws segments on colored backgrounds with arrow transitions:
+//
+//	dir  model  git 
+//
+// Section colors are replaced by the segment's own foreground so text stays
+// readable on every background. Segment colors may be any palette name or
+// color value accepted by colors.ParseColor.
+func renderPowerline(segments []segment, pl *config.Powerline, palette map[string]string) string {
+	theme, ok := powerlineThemes[pl.Theme]
+	if !ok {
+		theme = powerlineThemes["default"]
+	}
+
+	arrow := powerlineArrow
+	if pl.ASCII {
+		arrow = powerlineASCIIArrow
+	}
+
+	var out strings.Builder
+	prevBG := ""
+	rotation := 0
+
+	for i, seg := range segments {
+		style, ok := pl.Segments[seg.name]
+		if !ok {
+			style, ok = theme.sections[seg.name]
+		}
+		if !ok {
+			style = theme.rotation[rotation%len(theme.rotation)]
+			rotation++
+		}
+
+		fg, _ := colors.ParseColor(style.FG, palette)
+		fgBG, _ := colors.ParseColor(style.BG, palette)
+		bg := colors.Background(fgBG)
+
+		if i > 0 {
+			// Arrow in the previous background color on the new background
+			out.WriteString(colors.Reset + bg + prevBG + arrow)
+		}
+		out.WriteString(colors.Reset + bg + fg + " " + colors.Strip(seg.output) + " ")
+		prevBG = fgBG
+	}
+
+	if len(segments) > 0 {
+		// Closing arrow fades into the terminal background
+		out.WriteString(colors.Reset + prevBG + arrow + colors.Reset)
+	}
+
+	return out.String()
+}
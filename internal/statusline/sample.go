@@ -0,0 +1,146 @@
+package statusline
+
+import (
+	"time"
+
+	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/plugin"
+	"github.com/himattm/prism/internal/plugins"
+)
+
+// SampleInput returns a plausible mid-session input for projectDir, used to
+// preview a config without running Claude Code
+func SampleInput(projectDir string) Input {
+	return Input{
+		SessionID: "prism-preview",
+		Model:     ModelInfo{DisplayName: "Opus 4.5"},
+		Workspace: WorkspaceInfo{ProjectDir: projectDir, CurrentDir: projectDir},
+		Cost: CostInfo{
+			TotalCostUSD:      1.23,
+			TotalLinesAdded:   120,
+			TotalLinesRemoved: 34,
+		},
+		Context: ContextInfo{
+			CurrentUsage: ContextUsage{
+				InputTokens:     8000,
+				OutputTokens:    2000,
+				CacheReadTokens: 74000,
+			},
+			ContextWindow:       200000,
+			UsedPercentage:      42,
+			RemainingPercentage: 58,
+		},
+	}
+}
+
+// SetContext sets the context usage to pct percent of the window
+func (in *Input) SetContext(pct float64) {
+	window := in.Context.ContextWindow
+	if window == 0 {
+		window = 200000
+	}
+	tokens := int(float64(window) * pct / 100)
+	in.Context = ContextInfo{
+		CurrentUsage: ContextUsage{
+			InputTokens:     tokens / 10,
+			OutputTokens:    tokens / 40,
+			CacheReadTokens: tokens - tokens/10 - tokens/40,
+		},
+		ContextWindow:       window,
+		UsedPercentage:      pct,
+		RemainingPercentage: 100 - pct,
+	}
+}
+
+// Scenario is a named sample session for `prism preview`
+type Scenario struct {
+	Name        string
+	Description string
+	Input       Input
+	Usage       *plugins.UsageResponse // Plan limits shown by the usage sections (nil: API billing)
+}
+
+// Scenarios returns the built-in preview scenarios for projectDir
+func Scenarios(projectDir string) []Scenario {
+	now := time.Now()
+	limit := func(pct float64, resetsIn time.Duration) *plugins.UsageLimit {
+		return &plugins.UsageLimit{
+			Utilization: pct,
+			ResetsAt:    now.Add(resetsIn).UTC().Format(time.RFC3339),
+		}
+	}
+	proPlan := &plugins.UsageResponse{
+		FiveHour: limit(23, 3*time.Hour+12*time.Minute),
+		SevenDay: limit(41, 4*24*time.Hour),
+	}
+
+	withContext := func(pct float64) Input {
+		in := SampleInput(projectDir)
+		in.SetContext(pct)
+		return in
+	}
+
+	apiBilling := SampleInput(projectDir)
+	apiBilling.Model.DisplayName = "Sonnet 4.5"
+	apiBilling.Cost.TotalCostUSD = 4.87
+
+	return []Scenario{
+		{
+			Name:        "default",
+			Description: "Mid-session on a Pro plan, 42% context used",
+			Input:       SampleInput(projectDir),
+			Usage:       proPlan,
+		},
+		{
+			Name:        "low-context",
+			Description: "Fresh session, 8% context used",
+			Input:       withContext(8),
+			Usage:       proPlan,
+		},
+		{
+			Name:        "near-autocompact",
+			Description: "Long session, 93% context used (critical)",
+			Input:       withContext(93),
+			Usage:       proPlan,
+		},
+		{
+			Name:        "api-billing",
+			Description: "API key billing, the usage section shows the session cost",
+			Input:       apiBilling,
+		},
+		{
+			Name:        "max-plan",
+			Description: "Max plan close to its limits, including the Opus weekly limit",
+			Input:       withContext(64),
+			Usage: &plugins.UsageResponse{
+				FiveHour:     limit(78, 47*time.Minute),
+				SevenDay:     limit(55, 2*24*time.Hour+5*time.Hour),
+				SevenDayOpus: limit(91, 2*24*time.Hour+5*time.Hour),
+			},
+		},
+	}
+}
+
+// FindScenario returns the built-in scenario with the given name
+func FindScenario(projectDir, name string) (Scenario, bool) {
+	for _, s := range Scenarios(projectDir) {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// NewPreviewEngine creates an engine for rendering sample input: plugin
+// caches are memory-only, so previews never show or overwrite real session
+// data, and the usage sections show the given plan (nil: API billing)
+func NewPreviewEngine(usage *plugins.UsageResponse) *Engine {
+	c := cache.New()
+	plugins.PresetUsage(c, usage)
+	return &Engine{
+		pluginManager: plugin.NewManager(),
+		nativePlugins: plugins.NewRegistryWithCache(c),
+		statusCache:   cache.New(),
+		lastGood:      cache.New(),
+	}
+}
@@ -13,13 +13,23 @@ import (
 	"github.com/himattm/prism/internal/cache"
 	"github.com/himattm/prism/internal/colors"
 	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/format"
+	"github.com/himattm/prism/internal/logging"
 	"github.com/himattm/prism/internal/plugin"
 	"github.com/himattm/prism/internal/plugins"
 	"github.com/himattm/prism/internal/version"
 )
 
-// Package-level cache for statusline operations
-var statusCache = cache.New()
+const (
+	// sectionTimeout is how long a render waits for a fresh plugin value
+	sectionTimeout = 500 * time.Millisecond
+	// refreshTimeout bounds the background refresh once the render stops waiting
+	refreshTimeout = 5 * time.Second
+	// lastGoodTTL is how long a previous value may be shown as stale
+	lastGoodTTL = 1 * time.Hour
+	// hookTimeout bounds a persistent plugin's answer to a hook event
+	hookTimeout = 1 * time.Second
+)
 
 // StatusLine handles rendering the status line
 type StatusLine struct {
@@ -28,19 +38,180 @@ type StatusLine struct {
 	pluginManager   *plugin.Manager
 	nativePlugins   *plugins.Registry
 	isIdle          bool
-	bashPlugins     []plugin.Plugin // Cached discovered bash plugins
+	palette         map[string]string // Theme colors (nil = default palette)
+	bashPlugins     []plugin.Plugin   // Cached discovered bash plugins
 	bashPluginsOnce sync.Once
+	refreshes       sync.WaitGroup // Plugin runs still going after the render gave up on them
+	statusCache     *cache.Cache   // Worktree and diff stats, shared between invocations
+	lastGood        *cache.Cache   // Last known section outputs (see Engine)
+	statsMu         sync.Mutex
+	stats           []*SectionStat    // How each section rendered (see Stats)
+	env             map[string]string // Terminal environment to render for (nil = this process's, see SetEnv)
 }
 
-// New creates a new StatusLine renderer
-func New(input Input, cfg config.Config) *StatusLine {
+// Engine holds plugin state that can be shared by many renders.
+// A one-shot status line uses a fresh Engine; the daemon keeps one alive so
+// native plugin caches stay warm between requests.
+type Engine struct {
+	pluginManager *plugin.Manager
+	nativePlugins *plugins.Registry
+	statusCache   *cache.Cache
+
+	// lastGood remembers the most recent successful output of each plugin
+	// section (per project) so a slow section can show its previous value
+	// while a fresh one is computed in the background
+	lastGood *cache.Cache
+}
+
+// NewEngine creates an engine with all native plugins registered and the
+// persistent caches loaded
+func NewEngine() *Engine {
+	return &Engine{
+		pluginManager: plugin.NewManager(),
+		nativePlugins: plugins.NewRegistry(),
+		statusCache:   cache.NewPersistent(cache.Path("statusline")),
+		lastGood:      cache.NewPersistent(cache.Path("sections")),
+	}
+}
+
+// New creates a StatusLine renderer that uses the engine's plugins
+func (e *Engine) New(input Input, cfg config.Config) *StatusLine {
 	return &StatusLine{
 		input:         input,
 		config:        cfg,
-		pluginManager: plugin.NewManager(),
-		nativePlugins: plugins.NewRegistry(),
+		pluginManager: e.pluginManager,
+		nativePlugins: e.nativePlugins,
+		statusCache:   e.statusCache,
+		lastGood:      e.lastGood,
 		isIdle:        checkIsIdle(input.SessionID),
+		palette:       colors.Palette(cfg.Theme, cfg.Colors),
+	}
+}
+
+// New creates a new StatusLine renderer
+func New(input Input, cfg config.Config) *StatusLine {
+	return NewEngine().New(input, cfg)
+}
+
+// Hook sends a hook event to the subscribed persistent plugins the engine
+// has running and returns their notifications
+func (e *Engine) Hook(hookType, sessionID string, cfg config.Config) []string {
+	discovered, err := e.pluginManager.Discover()
+	if err != nil {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	outputs := make(map[string]string)
+	for _, p := range discovered {
+		if !e.pluginManager.Running(p) || !p.Subscribes(hookType) || e.pluginManager.Runnable(p, cfg.RequireVerifiedPlugins()) != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(p plugin.Plugin) {
+			defer wg.Done()
+			params := plugin.HookParams{
+				Type:      hookType,
+				SessionID: sessionID,
+				Config:    map[string]any{p.Name: p.Metadata.ApplyDefaults(cfg.LoadPluginConfig(p.Name))},
+			}
+			output, err := e.pluginManager.Hook(p, params, hookTimeout)
+			if err != nil {
+				logging.Warn("hook.plugin", "plugin", p.Name, "hook", hookType, "error", err)
+				return
+			}
+			logging.Info("hook.plugin", "plugin", p.Name, "hook", hookType)
+			if output != "" {
+				mu.Lock()
+				outputs[p.Name] = output
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	// In discovery order, so notifications do not shuffle
+	var result []string
+	for _, p := range discovered {
+		if output, ok := outputs[p.Name]; ok {
+			result = append(result, output)
+		}
+	}
+	return result
+}
+
+// StartedPersistent reports whether a render used a persistent plugin,
+// which only stays running between renders in a daemon
+func (e *Engine) StartedPersistent() bool {
+	return e.pluginManager.StartedPersistent()
+}
+
+// Close stops the persistent plugins the engine started
+func (e *Engine) Close() {
+	e.pluginManager.Close()
+}
+
+// terminalVars are the environment variables that change how a status line
+// renders, which a daemon must take from its client instead of its own:
+// color support, and COLUMNS for the width (see availableWidth)
+var terminalVars = append([]string{"COLUMNS"}, colors.EnvVars...)
+
+// TerminalEnv returns the variables of this process's environment that
+// change how a status line renders (see SetEnv)
+func TerminalEnv() map[string]string {
+	env := make(map[string]string)
+	for _, key := range terminalVars {
+		if value, ok := os.LookupEnv(key); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// SetEnv renders for a terminal with env (from its TerminalEnv) instead of
+// for this process's environment; variables missing from env count as unset
+func (sl *StatusLine) SetEnv(env map[string]string) {
+	sl.env = env
+}
+
+// getenv reads the environment of the terminal being rendered for
+func (sl *StatusLine) getenv(key string) string {
+	if sl.env != nil {
+		return sl.env[key]
 	}
+	return os.Getenv(key)
+}
+
+// colors returns the theme palette (default palette when not configured)
+func (sl *StatusLine) colors() map[string]string {
+	if sl.palette == nil {
+		sl.palette = colors.Palette("", nil)
+	}
+	return sl.palette
+}
+
+// sections returns the cache of last known section outputs (a throwaway one
+// when not set by the engine)
+func (sl *StatusLine) sections() *cache.Cache {
+	if sl.lastGood != nil {
+		return sl.lastGood
+	}
+	return cache.New()
+}
+
+// cache returns the cache for worktree and diff stats (a throwaway one when
+// not set by the engine)
+func (sl *StatusLine) cache() *cache.Cache {
+	if sl.statusCache != nil {
+		return sl.statusCache
+	}
+	return cache.New()
+}
+
+// color returns the escape code for a color name or semantic role
+func (sl *StatusLine) color(name string) string {
+	return sl.colors()[name]
 }
 
 // discoverBashPlugins discovers bash plugins once and caches them
@@ -68,57 +239,206 @@ func checkIsIdle(sessionID string) bool {
 	return true
 }
 
-// Render generates the status line output
+// Render generates the status line output. Sections render in parallel;
+// those still running when the render budget runs out are left out (or shown
+// with their last known value) and finish in the background (see Wait).
 func (sl *StatusLine) Render() string {
-	lines := sl.config.GetAllSectionLines()
-	var output []string
+	deadline := time.Now().Add(sl.config.GetRenderBudget())
 
+	lines := sl.config.GetAllSectionLines()
+	visible := make([][]string, len(lines))
 	for i, sections := range lines {
-		line := sl.renderLine(sections)
-		if line != "" {
-			// Prepend update indicator to first line only
-			if i == 0 {
-				updateOutput := sl.runUpdatePlugin()
-				if updateOutput != "" {
-					line = updateOutput + colors.Separator() + line
-				}
-			}
-			output = append(output, line)
+		visible[i] = sl.visibleSections(sections)
+	}
+	// The update indicator leads the first line
+	if len(visible) > 0 && len(visible[0]) > 0 {
+		visible[0] = append([]string{"update"}, visible[0]...)
+	}
+
+	results := sl.renderAll(visible, deadline)
+	sl.dedupeUsage(visible, results)
+
+	var output []string
+	for i, names := range visible {
+		segments := sl.segments(names, results[i])
+		if len(segments) == 1 && segments[0].name == "update" {
+			segments = nil // Only shown next to other sections
+		}
+		if len(segments) > 0 {
+			output = append(output, sl.layoutLine(segments))
 		}
 	}
 
-	return strings.Join(output, "\n")
+	// Persist cached results so the next refresh can reuse them
+	sl.cache().Flush()
+	sl.sections().Flush()
+	sl.nativePlugins.Flush()
+
+	// Adjust native and plugin colors alike to what the terminal supports
+	return colors.Downgrade(strings.Join(output, "\n"), colors.DetectLevelEnv(sl.config.ColorMode, sl.getenv))
 }
 
-func (sl *StatusLine) renderLine(sections []string) string {
-	// Run all sections in parallel
+// Wait blocks until background section refreshes started by Render finish,
+// then persists their results for the next render. One-shot callers should
+// call it after writing the output; long-lived callers can skip it.
+func (sl *StatusLine) Wait() {
+	sl.refreshes.Wait()
+	sl.sections().Flush()
+	sl.nativePlugins.Flush()
+}
+
+// WaitTimeout is like Wait but stops waiting for refreshes after d; the
+// results that came in by then are still persisted
+func (sl *StatusLine) WaitTimeout(d time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		sl.refreshes.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	sl.sections().Flush()
+	sl.nativePlugins.Flush()
+}
+
+// renderAll renders the sections of every line in parallel and returns
+// their outputs, indexed like lines. It returns at the deadline at the
+// latest; a section still running by then gets its last known value, dimmed,
+// or is left empty.
+func (sl *StatusLine) renderAll(lines [][]string, deadline time.Time) [][]string {
 	type result struct {
-		index  int
-		output string
+		line, idx int
+		output    string
 	}
 
-	results := make([]string, len(sections))
-	var wg sync.WaitGroup
+	results := make([][]string, len(lines))
+	pending := 0
+	for i, sections := range lines {
+		results[i] = make([]string, len(sections))
+		pending += len(sections)
+	}
+	done := make(chan result, pending) // Buffered so late sections never block
 
-	for i, section := range sections {
-		wg.Add(1)
-		go func(idx int, sec string) {
-			defer wg.Done()
-			results[idx] = sl.renderSection(sec)
-		}(i, section)
+	for i, sections := range lines {
+		for j, section := range sections {
+			sl.refreshes.Add(1)
+			go func(line, idx int, sec string) {
+				defer sl.refreshes.Done()
+				output := sl.timeSection(sec, func() string { return sl.renderSection(sec) })
+				done <- result{line, idx, output}
+			}(i, j, section)
+		}
 	}
 
-	wg.Wait()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	received := make([][]bool, len(lines))
+	for i := range lines {
+		received[i] = make([]bool, len(lines[i]))
+	}
+	for pending > 0 {
+		select {
+		case r := <-done:
+			results[r.line][r.idx] = r.output
+			received[r.line][r.idx] = true
+			pending--
+		case <-timer.C:
+			for i, sections := range lines {
+				for j, section := range sections {
+					if !received[i][j] {
+						results[i][j] = sl.overBudget(section)
+					}
+				}
+			}
+			return results
+		}
+	}
+	return results
+}
+
+// overBudget returns what to show for a section that missed the render
+// budget: its last known value, dimmed, or nothing
+func (sl *StatusLine) overBudget(section string) string {
+	stale, ok := sl.sections().Get(fmt.Sprintf("%s:%s", section, sl.input.Workspace.ProjectDir))
+	sl.record(section, func(s *SectionStat) {
+		s.TimedOut = true
+		s.Cache = "miss"
+		if ok {
+			s.Cache = "hit"
+		} else {
+			s.Hidden = "over render budget"
+		}
+	})
+	logging.Warn("render.budget", "section", section, "budget", sl.config.GetRenderBudget())
+	if ok {
+		return markStale(stale)
+	}
+	return ""
+}
+
+// dedupeUsage keeps only the first usage section with output in this
+// render: usage, usage_bars and usage_text show the same plan usage
+func (sl *StatusLine) dedupeUsage(names, outputs [][]string) {
+	shown := false
+	for i := range names {
+		for j, name := range names[i] {
+			if outputs[i][j] == "" || !plugins.IsUsageSection(name) {
+				continue
+			}
+			if shown {
+				outputs[i][j] = ""
+				sl.record(name, func(s *SectionStat) { s.Hidden = "another usage section is shown" })
+			}
+			shown = true
+		}
+	}
+}
 
-	// Filter empty and join (preserving order)
-	var parts []string
-	for _, out := range results {
+// segments pairs rendered outputs with their section names, dropping empty ones
+func (sl *StatusLine) segments(names, outputs []string) []segment {
+	var segments []segment
+	for i, out := range outputs {
 		if out != "" {
-			parts = append(parts, out)
+			segments = append(segments, segment{
+				name:    names[i],
+				output:  out,
+				compact: sl.compactSection(names[i], out),
+			})
 		}
 	}
+	return segments
+}
 
-	return strings.Join(parts, colors.Separator())
+// builtinSections are rendered from the input without plugins
+var builtinSections = map[string]bool{
+	"dir":          true,
+	"model":        true,
+	"context":      true,
+	"linesChanged": true,
+	"cost":         true,
+}
+
+// timeSection renders a section and records how long it took
+func (sl *StatusLine) timeSection(name string, render func() string) string {
+	start := time.Now()
+	output := render()
+	elapsed := time.Since(start)
+
+	sl.record(name, func(s *SectionStat) {
+		s.Duration = elapsed
+		if builtinSections[name] {
+			s.Kind = "builtin"
+		}
+		if output == "" && s.Err == nil && !s.TimedOut && s.Hidden == "" {
+			s.Hidden = "empty output"
+		}
+	})
+	return output
 }
 
 func (sl *StatusLine) renderSection(section string) string {
@@ -164,16 +484,38 @@ func (sl *StatusLine) renderDir() string {
 	// Check if we're in a worktree (prepend ⎇ indicator)
 	worktreeIndicator := ""
 	if sl.isWorktree() {
-		worktreeIndicator = fmt.Sprintf("%s⎇%s ", colors.Cyan, colors.Reset)
+		worktreeIndicator = fmt.Sprintf("%s⎇%s ", sl.color("dir"), colors.Reset)
 	}
 
+	var output string
 	if subdir != "" {
-		return fmt.Sprintf("%s%s%s%s%s%s%s",
-			icon, worktreeIndicator, colors.Dim, colors.Cyan, projectName, colors.Reset,
-			colors.Wrap(colors.Cyan, subdir))
+		output = fmt.Sprintf("%s%s%s%s%s%s%s",
+			icon, worktreeIndicator, colors.Dim, sl.color("dir"), projectName, colors.Reset,
+			colors.Wrap(sl.color("dir"), subdir))
+	} else {
+		output = fmt.Sprintf("%s%s%s", icon, worktreeIndicator, colors.Wrap(sl.color("dir"), projectName))
 	}
 
-	return fmt.Sprintf("%s%s%s", icon, worktreeIndicator, colors.Wrap(colors.Cyan, projectName))
+	return sl.applyFormat("dir", map[string]any{
+		"icon":     sl.config.Icon,
+		"project":  projectName,
+		"subdir":   subdir,
+		"worktree": worktreeIndicator != "",
+	}, output)
+}
+
+// applyFormat renders a built-in section's "format" template from the plugins
+// config, falling back to the default output if none is set or it fails
+func (sl *StatusLine) applyFormat(section string, fields map[string]any, fallback string) string {
+	tmpl := format.Get(sl.config.Plugins, section)
+	if tmpl == "" {
+		return fallback
+	}
+	output, err := format.Render(tmpl, fields, sl.colors())
+	if err != nil {
+		return fallback
+	}
+	return output
 }
 
 // isWorktree returns true if the project directory is a git worktree
@@ -184,6 +526,7 @@ func (sl *StatusLine) isWorktree() bool {
 	}
 
 	// Check cache first (worktree status rarely changes)
+	statusCache := sl.cache()
 	cacheKey := "worktree:" + projectDir
 	if cached, ok := statusCache.Get(cacheKey); ok {
 		return cached == "true"
@@ -207,13 +550,25 @@ func (sl *StatusLine) isWorktree() bool {
 }
 
 func (sl *StatusLine) renderModel() string {
-	return colors.Wrap(colors.Magenta, sl.input.Model.DisplayName)
+	return sl.applyFormat("model", map[string]any{
+		"model": sl.input.Model.DisplayName,
+	}, colors.Wrap(sl.color("model"), sl.input.Model.DisplayName))
 }
 
 func (sl *StatusLine) renderContext() string {
 	// Get autocompact buffer from config (default 22.5%)
 	bufferPct := sl.config.GetAutocompactBuffer()
+	pct := sl.contextPct()
+
+	return sl.applyFormat("context", map[string]any{
+		"pct":   pct,
+		"bar":   contextBar(pct, bufferPct > 0),
+		"state": contextState(pct),
+	}, renderContextBar(pct, bufferPct > 0, sl.colors()))
+}
 
+// contextPct returns the actionable context usage (0-100) for the context bar
+func (sl *StatusLine) contextPct() int {
 	// Check if Claude Code provided the new percentage fields (2.1.6+)
 	// Use used_percentage directly, or calculate from remaining_percentage
 	if sl.input.Context.UsedPercentage > 0 || sl.input.Context.RemainingPercentage > 0 {
@@ -230,12 +585,11 @@ func (sl *StatusLine) renderContext() string {
 		if pct < 0 {
 			pct = 0
 		}
-		return renderContextBar(pct, bufferPct > 0)
+		return pct
 	}
 
 	// Fall back to legacy calculation for older Claude Code versions
-	pct := sl.calculateContextPctLegacy()
-	return renderContextBar(pct, bufferPct > 0)
+	return sl.calculateContextPctLegacy()
 }
 
 func (sl *StatusLine) calculateContextPctLegacy() int {
@@ -263,8 +617,20 @@ func (sl *StatusLine) calculateContextPctLegacy() int {
 	return pct
 }
 
-func renderContextBar(pct int, showBuffer bool) string {
-	// 10-char bar: ████░░░░▒▒ (with buffer) or ████░░░░░░ (without)
+// contextState classifies usage as "ok", "warning" (70%+) or "critical" (90%+)
+func contextState(pct int) string {
+	switch {
+	case pct >= 90:
+		return "critical"
+	case pct >= 70:
+		return "warning"
+	default:
+		return "ok"
+	}
+}
+
+// contextBar returns the uncolored 10-char bar: ████░░░░▒▒ (with buffer) or ████░░░░░░ (without)
+func contextBar(pct int, showBuffer bool) string {
 	// No end caps for a cleaner look
 	const barLen = 10
 	filled := (pct * barLen) / 100
@@ -276,14 +642,28 @@ func renderContextBar(pct int, showBuffer bool) string {
 	// Only show if autocompact buffer is enabled
 	bufferStart := 8 // Last 2 chars for buffer indicator
 
-	// Choose color based on percentage: white -> yellow -> red
+	var bar strings.Builder
+	for i := 0; i < barLen; i++ {
+		if i < filled {
+			bar.WriteString("█")
+		} else if showBuffer && i >= bufferStart {
+			bar.WriteString("▒")
+		} else {
+			bar.WriteString("░")
+		}
+	}
+	return bar.String()
+}
+
+func renderContextBar(pct int, showBuffer bool, palette map[string]string) string {
+	// Choose color based on percentage: white -> warning -> critical
 	// When colored, the entire bar is that color for uniformity
 	var barColor string
-	switch {
-	case pct >= 90:
-		barColor = colors.Red
-	case pct >= 70:
-		barColor = colors.Yellow
+	switch contextState(pct) {
+	case "critical":
+		barColor = palette["critical"]
+	case "warning":
+		barColor = palette["warning"]
 	default:
 		barColor = "" // White/default
 	}
@@ -295,16 +675,7 @@ func renderContextBar(pct int, showBuffer bool) string {
 		bar.WriteString(barColor)
 	}
 
-	for i := 0; i < barLen; i++ {
-		if i < filled {
-			bar.WriteString("█")
-		} else if showBuffer && i >= bufferStart {
-			bar.WriteString("▒")
-		} else {
-			bar.WriteString("░")
-		}
-	}
-
+	bar.WriteString(contextBar(pct, showBuffer))
 	bar.WriteString(fmt.Sprintf(" %d%%", pct))
 
 	if barColor != "" {
@@ -317,14 +688,17 @@ func renderContextBar(pct int, showBuffer bool) string {
 func (sl *StatusLine) renderLinesChanged() string {
 	// ALWAYS use git diff stats - never use Claude's session stats
 	// This shows actual uncommitted changes in the working tree
-	added, removed := getGitDiffStats(sl.input.Workspace.ProjectDir)
-
-	return fmt.Sprintf("%s+%d%s %s-%d%s",
-		colors.Green, added, colors.Reset,
-		colors.Red, removed, colors.Reset)
+	added, removed := getGitDiffStats(sl.cache(), sl.input.Workspace.ProjectDir)
+
+	return sl.applyFormat("linesChanged", map[string]any{
+		"added":   added,
+		"removed": removed,
+	}, fmt.Sprintf("%s+%d%s %s-%d%s",
+		sl.color("added"), added, colors.Reset,
+		sl.color("removed"), removed, colors.Reset))
 }
 
-func getGitDiffStats(projectDir string) (int, int) {
+func getGitDiffStats(statusCache *cache.Cache, projectDir string) (int, int) {
 	if projectDir == "" {
 		return 0, 0
 	}
@@ -361,12 +735,77 @@ func getGitDiffStats(projectDir string) (int, int) {
 
 func (sl *StatusLine) renderCost() string {
 	cost := sl.input.Cost.TotalCostUSD
-	return colors.Wrap(colors.Gray, fmt.Sprintf("$%.2f", cost))
+	return sl.applyFormat("cost", map[string]any{
+		"cost": cost,
+	}, colors.Wrap(sl.color("muted"), fmt.Sprintf("$%.2f", cost)))
 }
 
 func (sl *StatusLine) runPlugin(name string) string {
-	// Build plugin input
-	input := plugin.Input{
+	input := sl.buildPluginInput(name)
+	key := fmt.Sprintf("%s:%s", name, sl.input.Workspace.ProjectDir)
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+
+	// The plugin keeps running after we stop waiting so its result can
+	// become the next render's value (stale-while-revalidate)
+	sl.refreshes.Add(1)
+	go func() {
+		defer sl.refreshes.Done()
+		start := time.Now()
+		output, err := sl.executePlugin(name, input)
+		elapsed := time.Since(start)
+		sl.record(name, func(s *SectionStat) {
+			s.Refresh = elapsed
+			s.Err = err
+		})
+		if err == nil {
+			if output != "" {
+				sl.sections().Set(key, output, lastGoodTTL)
+			} else {
+				sl.sections().Delete(key)
+			}
+		}
+		done <- result{output, err}
+	}()
+
+	timer := time.NewTimer(sectionTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		if r.err == nil {
+			return r.output
+		}
+	case <-timer.C:
+		sl.record(name, func(s *SectionStat) { s.TimedOut = true })
+		logging.Warn("plugin.timeout", "plugin", name, "deadline", sectionTimeout)
+	}
+
+	// Slow or failing: show the last known good value, dimmed to mark it stale
+	stale, ok := sl.sections().Get(key)
+	sl.record(name, func(s *SectionStat) {
+		s.Cache = "miss"
+		if ok {
+			s.Cache = "hit"
+		}
+	})
+	if ok {
+		return markStale(stale)
+	}
+	return ""
+}
+
+// markStale renders a previous section value in a uniform dim style
+func markStale(output string) string {
+	return colors.Wrap(colors.Dim, colors.Strip(output))
+}
+
+func (sl *StatusLine) buildPluginInput(name string) plugin.Input {
+	return plugin.Input{
 		Prism: plugin.PrismContext{
 			Version:    version.Version,
 			ProjectDir: sl.input.Workspace.ProjectDir,
@@ -382,50 +821,74 @@ func (sl *StatusLine) runPlugin(name string) string {
 			LinesRemoved: sl.input.Cost.TotalLinesRemoved,
 		},
 		Config: sl.getPluginConfig(name),
-		Colors: colors.ColorMap(),
+		Colors: sl.colors(),
 	}
+}
+
+// executePlugin runs a native plugin, falling back to a bash plugin of the same name
+func (sl *StatusLine) executePlugin(name string, input plugin.Input) (string, error) {
+	var nativeErr error
 
 	// Try native plugin first (much faster - no subprocess)
 	if native := sl.nativePlugins.Get(name); native != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
 		defer cancel()
 
+		sl.record(name, func(s *SectionStat) { s.Kind = "native" })
+		start := time.Now()
 		output, err := native.Execute(ctx, input)
+		logPlugin(name, "native", time.Since(start), err)
 		if err == nil {
-			return output
+			return output, nil
 		}
 		// Fall through to bash plugin on error
+		nativeErr = err
 	}
 
 	// Fall back to bash plugin
-	return sl.runBashPlugin(name, input)
-}
-
-func (sl *StatusLine) runBashPlugin(name string, input plugin.Input) string {
-	bashPlugins := sl.discoverBashPlugins()
-
-	var targetPlugin *plugin.Plugin
-	for _, p := range bashPlugins {
-		if p.Name == name {
-			targetPlugin = &p
-			break
+	target := sl.findBashPlugin(name)
+	if target == nil {
+		// Unknown section, or a failed native plugin with nothing to fall back to
+		if nativeErr == nil {
+			sl.record(name, func(s *SectionStat) { s.Hidden = "no section or plugin with this name" })
 		}
+		return "", nativeErr
 	}
 
-	if targetPlugin == nil {
-		return ""
+	sl.record(name, func(s *SectionStat) { s.Kind = "script" })
+
+	// The plugin's manifest may rule it out here (e.g. a missing adb), or
+	// its file may no longer match the digest recorded at install
+	if err := sl.pluginManager.Runnable(*target, sl.config.RequireVerifiedPlugins()); err != nil {
+		sl.record(name, func(s *SectionStat) { s.Hidden = err.Error() })
+		logging.Debug("plugin", "plugin", name, "skipped", err)
+		return "", nil
 	}
+	cfg, _ := input.Config[name].(map[string]any)
+	input.Config = map[string]any{name: target.Metadata.ApplyDefaults(cfg)}
+
+	start := time.Now()
+	output, err := sl.pluginManager.Execute(*target, input, target.Metadata.RunTimeout(refreshTimeout))
+	logPlugin(name, "script", time.Since(start), err)
+	return output, err
+}
 
-	output, err := sl.pluginManager.Execute(*targetPlugin, input, 500*time.Millisecond)
+// logPlugin writes a plugin execution to the debug log
+func logPlugin(name, kind string, elapsed time.Duration, err error) {
 	if err != nil {
-		return ""
+		logging.Warn("plugin", "plugin", name, "kind", kind, "duration", elapsed, "error", err)
+		return
 	}
-
-	return output
+	logging.Debug("plugin", "plugin", name, "kind", kind, "duration", elapsed)
 }
 
-func (sl *StatusLine) runUpdatePlugin() string {
-	return sl.runPlugin("update")
+func (sl *StatusLine) findBashPlugin(name string) *plugin.Plugin {
+	for _, p := range sl.discoverBashPlugins() {
+		if p.Name == name {
+			return &p
+		}
+	}
+	return nil
 }
 
 func (sl *StatusLine) calculateContextPct() int {
@@ -1,13 +1,20 @@
 package statusline
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/himattm/prism/internal/cache"
+	"github.com/himattm/prism/internal/colors"
 	"github.com/himattm/prism/internal/config"
+	"github.com/himattm/prism/internal/plugin"
+	"github.com/himattm/prism/internal/plugins"
 )
 
 // TestRenderLinesChanged_NeverUsesClaudeStats verifies that linesChanged
@@ -121,7 +128,7 @@ func TestRenderLinesChanged_IdleStateDoesNotAffectBehavior(t *testing.T) {
 
 // TestGetGitDiffStats_EmptyDir returns 0,0 for empty project dir
 func TestGetGitDiffStats_EmptyDir(t *testing.T) {
-	added, removed := getGitDiffStats("")
+	added, removed := getGitDiffStats(cache.New(), "")
 	if added != 0 || removed != 0 {
 		t.Errorf("expected 0,0 for empty dir, got %d,%d", added, removed)
 	}
@@ -135,7 +142,7 @@ func TestGetGitDiffStats_NotGitRepo(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	added, removed := getGitDiffStats(tmpDir)
+	added, removed := getGitDiffStats(cache.New(), tmpDir)
 	if added != 0 || removed != 0 {
 		t.Errorf("expected 0,0 for non-git dir, got %d,%d", added, removed)
 	}
@@ -146,7 +153,7 @@ func TestGetGitDiffStats_CleanRepo(t *testing.T) {
 	tmpDir := setupTestGitRepo(t)
 	defer os.RemoveAll(tmpDir)
 
-	added, removed := getGitDiffStats(tmpDir)
+	added, removed := getGitDiffStats(cache.New(), tmpDir)
 	if added != 0 || removed != 0 {
 		t.Errorf("expected 0,0 for clean repo, got %d,%d", added, removed)
 	}
@@ -161,7 +168,7 @@ func TestGetGitDiffStats_WithChanges(t *testing.T) {
 	readmeFile := filepath.Join(tmpDir, "README.md")
 	os.WriteFile(readmeFile, []byte("new content\nline 2\nline 3\n"), 0644)
 
-	added, removed := getGitDiffStats(tmpDir)
+	added, removed := getGitDiffStats(cache.New(), tmpDir)
 
 	// Original had 1 line ("# Test"), new has 3 lines
 	// So we should see additions and the original line removed
@@ -179,7 +186,7 @@ func TestGetGitDiffStats_NewUntrackedFile(t *testing.T) {
 	newFile := filepath.Join(tmpDir, "untracked.txt")
 	os.WriteFile(newFile, []byte("untracked content\n"), 0644)
 
-	added, removed := getGitDiffStats(tmpDir)
+	added, removed := getGitDiffStats(cache.New(), tmpDir)
 
 	// git diff HEAD doesn't show untracked files
 	if added != 0 || removed != 0 {
@@ -200,7 +207,7 @@ func TestGetGitDiffStats_StagedChanges(t *testing.T) {
 	cmd.Dir = tmpDir
 	cmd.Run()
 
-	added, removed := getGitDiffStats(tmpDir)
+	added, removed := getGitDiffStats(cache.New(), tmpDir)
 
 	// git diff HEAD shows staged changes
 	if added != 2 {
@@ -294,7 +301,7 @@ func TestNew_CreatesStatusLine(t *testing.T) {
 
 // TestRenderContextBar_NoBrackets verifies brackets were removed
 func TestRenderContextBar_NoBrackets(t *testing.T) {
-	result := renderContextBar(50, false)
+	result := renderContextBar(50, false, colors.Palette("", nil))
 
 	if strings.Contains(result, "[") || strings.Contains(result, "]") {
 		t.Errorf("context bar should not contain brackets: %s", result)
@@ -320,7 +327,7 @@ func TestRenderContextBar_Percentages(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := renderContextBar(tt.pct, false)
+		result := renderContextBar(tt.pct, false, colors.Palette("", nil))
 		fillCount := strings.Count(result, "█")
 		if fillCount != tt.expectedFill {
 			t.Errorf("at %d%%, expected %d filled blocks, got %d: %s",
@@ -332,13 +339,13 @@ func TestRenderContextBar_Percentages(t *testing.T) {
 // TestRenderContextBar_BufferZone verifies buffer zone rendering
 func TestRenderContextBar_BufferZone(t *testing.T) {
 	// With buffer enabled, should have ▒ characters at the end
-	withBuffer := renderContextBar(50, true)
+	withBuffer := renderContextBar(50, true, colors.Palette("", nil))
 	if !strings.Contains(withBuffer, "▒") {
 		t.Errorf("buffer zone should show ▒ when enabled: %s", withBuffer)
 	}
 
 	// Without buffer, should not have ▒ characters
-	withoutBuffer := renderContextBar(50, false)
+	withoutBuffer := renderContextBar(50, false, colors.Palette("", nil))
 	if strings.Contains(withoutBuffer, "▒") {
 		t.Errorf("buffer zone should not show ▒ when disabled: %s", withoutBuffer)
 	}
@@ -599,3 +606,537 @@ func TestRenderDir_NoIndicatorForMainRepo(t *testing.T) {
 		t.Errorf("renderDir should not include ⎇ indicator for main repo, got: %s", result)
 	}
 }
+
+// fakePlugin is a native plugin whose output, delay and error are controlled by tests
+type fakePlugin struct {
+	name   string
+	output string
+	delay  time.Duration
+	err    error
+}
+
+func (p *fakePlugin) Name() string            { return p.name }
+func (p *fakePlugin) SetCache(c *cache.Cache) {}
+func (p *fakePlugin) Execute(ctx context.Context, input plugin.Input) (string, error) {
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	return p.output, p.err
+}
+
+func newFakePluginStatusLine(t *testing.T, fake *fakePlugin) *StatusLine {
+	t.Helper()
+	registry := plugins.NewRegistryWithCache(cache.New())
+	registry.Register(fake)
+
+	projectDir, err := os.MkdirTemp("", "prism-test-swr-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(projectDir) })
+
+	return &StatusLine{
+		input:         Input{Workspace: WorkspaceInfo{ProjectDir: projectDir}},
+		pluginManager: plugin.NewManager(),
+		nativePlugins: registry,
+		lastGood:      cache.New(),
+	}
+}
+
+// TestRunPlugin_SlowSectionShowsStaleValue verifies stale-while-revalidate:
+// a section that misses the deadline shows its last good value, dimmed
+func TestRunPlugin_SlowSectionShowsStaleValue(t *testing.T) {
+	fake := &fakePlugin{name: "test_swr_slow", output: colors.Wrap(colors.Yellow, "main*")}
+	sl := newFakePluginStatusLine(t, fake)
+
+	if got := sl.runPlugin(fake.name); got != fake.output {
+		t.Fatalf("expected fresh output %q, got %q", fake.output, got)
+	}
+
+	// Now the plugin is too slow for the render deadline
+	fake.delay = sectionTimeout + 200*time.Millisecond
+	fake.output = "develop"
+	got := sl.runPlugin(fake.name)
+	if got != colors.Wrap(colors.Dim, "main*") {
+		t.Errorf("expected dimmed stale output, got %q", got)
+	}
+
+	// The background refresh updates the stored value for the next render
+	sl.refreshes.Wait()
+	fake.delay = 0
+	fake.err = errors.New("boom")
+	if got := sl.runPlugin(fake.name); got != colors.Wrap(colors.Dim, "develop") {
+		t.Errorf("expected refreshed stale value after failure, got %q", got)
+	}
+}
+
+// TestWaitTimeout_GivesUpOnSlowRefresh keeps a one-shot process from
+// waiting out the whole refresh timeout
+func TestWaitTimeout_GivesUpOnSlowRefresh(t *testing.T) {
+	fake := &fakePlugin{name: "test_wait_slow", output: "slow", delay: sectionTimeout + 2*time.Second}
+	sl := newFakePluginStatusLine(t, fake)
+	sl.runPlugin(fake.name)
+
+	start := time.Now()
+	sl.WaitTimeout(100 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitTimeout took %s, want about 100ms", elapsed)
+	}
+}
+
+// TestRunPlugin_EmptyOutputClearsStaleValue ensures a section that legitimately
+// renders nothing (e.g. devices unplugged) doesn't resurrect old output
+func TestRunPlugin_EmptyOutputClearsStaleValue(t *testing.T) {
+	fake := &fakePlugin{name: "test_swr_empty", output: "⬡ emulator"}
+	sl := newFakePluginStatusLine(t, fake)
+
+	sl.runPlugin(fake.name)
+
+	fake.output = ""
+	if got := sl.runPlugin(fake.name); got != "" {
+		t.Fatalf("expected empty output, got %q", got)
+	}
+
+	fake.err = errors.New("adb failed")
+	if got := sl.runPlugin(fake.name); got != "" {
+		t.Errorf("stale value should have been cleared, got %q", got)
+	}
+}
+
+// TestRunPlugin_NoStaleValue returns empty when nothing was ever rendered
+func TestRunPlugin_NoStaleValue(t *testing.T) {
+	fake := &fakePlugin{name: "test_swr_none", err: errors.New("fails")}
+	sl := newFakePluginStatusLine(t, fake)
+
+	if got := sl.runPlugin(fake.name); got != "" {
+		t.Errorf("expected empty output, got %q", got)
+	}
+}
+
+// TestApplyFormat_BuiltinSections verifies per-section format templates
+func TestApplyFormat_BuiltinSections(t *testing.T) {
+	sl := &StatusLine{
+		input: Input{
+			Model:   ModelInfo{DisplayName: "Opus 4.5"},
+			Context: ContextInfo{UsedPercentage: 75},
+		},
+		config: config.Config{
+			Plugins: map[string]any{
+				"model":   map[string]any{"format": "[{{.model}}]"},
+				"context": map[string]any{"format": "{{.pct}}% {{.state}}"},
+			},
+		},
+	}
+
+	if got := sl.renderModel(); got != "[Opus 4.5]" {
+		t.Errorf("expected formatted model, got %q", got)
+	}
+	if got := sl.renderContext(); got != "75% warning" {
+		t.Errorf("expected formatted context, got %q", got)
+	}
+}
+
+// TestApplyFormat_InvalidTemplateFallsBack keeps the default look on template errors
+func TestApplyFormat_InvalidTemplateFallsBack(t *testing.T) {
+	sl := &StatusLine{
+		input: Input{Model: ModelInfo{DisplayName: "Opus 4.5"}},
+		config: config.Config{
+			Plugins: map[string]any{
+				"model": map[string]any{"format": "{{.nope}}"},
+			},
+		},
+	}
+
+	if got := sl.renderModel(); got != colors.Wrap(colors.Magenta, "Opus 4.5") {
+		t.Errorf("expected default model output, got %q", got)
+	}
+}
+
+// TestRenderPowerline draws segments with backgrounds and arrow transitions
+func TestRenderPowerline(t *testing.T) {
+	segments := []segment{
+		{name: "dir", output: colors.Wrap(colors.Cyan, "prism")},
+		{name: "git", output: colors.Wrap(colors.Yellow, "main*")},
+	}
+	pl := &config.Powerline{
+		Enabled: true,
+		ASCII:   true,
+		Segments: map[string]config.SegmentStyle{
+			"dir": {FG: "black", BG: "blue"},
+			"git": {FG: "white", BG: "red"},
+		},
+	}
+
+	got := renderPowerline(segments, pl, colors.Palette("", nil))
+
+	expected := colors.Reset + "\033[44m" + colors.Black + " prism " +
+		colors.Reset + "\033[41m" + colors.Blue + ">" +
+		colors.Reset + "\033[41m" + colors.White + " main* " +
+		colors.Reset + colors.Red + ">" + colors.Reset
+	if got != expected {
+		t.Errorf("unexpected powerline output:\n got: %q\nwant: %q", got, expected)
+	}
+	if strings.Contains(got, colors.Yellow) {
+		t.Error("section colors should be replaced by segment colors")
+	}
+}
+
+// TestRenderPowerline_ThemeFallback uses the default theme for unknown names
+func TestRenderPowerline_ThemeFallback(t *testing.T) {
+	segments := []segment{{name: "custom_plugin", output: "hi"}}
+	got := renderPowerline(segments, &config.Powerline{Enabled: true, Theme: "nope"}, colors.Palette("", nil))
+
+	if !strings.Contains(got, " hi ") || !strings.Contains(got, powerlineArrow) {
+		t.Errorf("expected padded segment with powerline arrow, got %q", got)
+	}
+}
+
+func newLayoutStatusLine(width int, dropOrder []string) *StatusLine {
+	return &StatusLine{
+		input: Input{
+			Workspace: WorkspaceInfo{ProjectDir: "/tmp/prism"},
+			Model:     ModelInfo{DisplayName: "Opus 4.5"},
+		},
+		config: config.Config{Width: width, DropOrder: dropOrder},
+	}
+}
+
+func layoutSegments(sl *StatusLine) []segment {
+	outputs := map[string]string{
+		"dir":   colors.Wrap(colors.Cyan, "prism/internal/statusline"),
+		"model": colors.Wrap(colors.Magenta, "Opus 4.5"),
+		"git":   colors.Wrap(colors.Yellow, "feature/a-very-long-branch-name*"),
+		"usage": "5h 12%",
+	}
+	var segments []segment
+	for _, name := range []string{"dir", "model", "git", "usage"} {
+		segments = append(segments, segment{
+			name:    name,
+			output:  outputs[name],
+			compact: sl.compactSection(name, outputs[name]),
+		})
+	}
+	return segments
+}
+
+// TestLayoutLine_FitsUnchanged leaves lines alone when there is room
+func TestLayoutLine_FitsUnchanged(t *testing.T) {
+	sl := newLayoutStatusLine(200, nil)
+	segments := layoutSegments(sl)
+
+	if got, want := sl.layoutLine(segments), sl.joinSegments(layoutSegments(sl)); got != want {
+		t.Errorf("expected unchanged line %q, got %q", want, got)
+	}
+}
+
+// TestAvailableWidth_ForwardedColumns uses the client's COLUMNS when a
+// daemon renders, not its own
+func TestAvailableWidth_ForwardedColumns(t *testing.T) {
+	t.Setenv("COLUMNS", "200")
+	sl := newLayoutStatusLine(0, nil)
+	if got := sl.availableWidth(); got != 200 {
+		t.Errorf("own COLUMNS: width %d, want 200", got)
+	}
+
+	sl.SetEnv(map[string]string{"COLUMNS": "60"})
+	if got := sl.availableWidth(); got != 60 {
+		t.Errorf("forwarded COLUMNS: width %d, want 60", got)
+	}
+	sl.SetEnv(map[string]string{})
+	if got := sl.availableWidth(); got != 0 {
+		t.Errorf("client without COLUMNS: width %d, want unlimited", got)
+	}
+	if env := TerminalEnv(); env["COLUMNS"] != "200" {
+		t.Errorf("TerminalEnv = %v, want COLUMNS forwarded", env)
+	}
+}
+
+// TestLayoutLine_CompactsBeforeDropping shortens sections before removing any
+func TestLayoutLine_CompactsBeforeDropping(t *testing.T) {
+	sl := newLayoutStatusLine(50, nil)
+	got := sl.layoutLine(layoutSegments(sl))
+
+	if w := colors.Width(got); w > 50 {
+		t.Errorf("line is %d columns, want <= 50: %q", w, got)
+	}
+	plain := colors.Strip(got)
+	if !strings.Contains(plain, "5h 12%") {
+		t.Errorf("usage should survive via compact variants, got %q", plain)
+	}
+	if !strings.Contains(plain, "…") {
+		t.Errorf("expected the long branch to be truncated, got %q", plain)
+	}
+}
+
+// TestLayoutLine_DropsInConfiguredOrder drops dropOrder sections first,
+// then the rest right to left, always keeping one section
+func TestLayoutLine_DropsInConfiguredOrder(t *testing.T) {
+	sl := newLayoutStatusLine(30, []string{"git"})
+	plain := colors.Strip(sl.layoutLine(layoutSegments(sl)))
+
+	if strings.Contains(plain, "feature") {
+		t.Errorf("git should be dropped first, got %q", plain)
+	}
+	if !strings.Contains(plain, "prism") {
+		t.Errorf("dir should be kept, got %q", plain)
+	}
+	if colors.Width(plain) > 30 {
+		t.Errorf("line too wide: %q", plain)
+	}
+
+	sl = newLayoutStatusLine(3, nil)
+	plain = colors.Strip(sl.layoutLine(layoutSegments(sl)))
+	if plain != "prism" {
+		t.Errorf("expected only the first section to remain, got %q", plain)
+	}
+}
+
+// TestLayoutLine_DropsUpdateFirst never keeps the update indicator, which
+// leads the first line, over the sections the user configured
+func TestLayoutLine_DropsUpdateFirst(t *testing.T) {
+	sl := newLayoutStatusLine(3, nil)
+	segments := append([]segment{{name: "update", output: "⬆ 1.2.3"}}, layoutSegments(sl)...)
+
+	if plain := colors.Strip(sl.layoutLine(segments)); plain != "prism" {
+		t.Errorf("expected the first configured section to remain, got %q", plain)
+	}
+}
+
+// TestCompactSection_Context shrinks the context bar to the percentage
+func TestCompactSection_Context(t *testing.T) {
+	sl := newLayoutStatusLine(0, nil)
+	sl.input.Context.UsedPercentage = 56
+
+	if got := sl.compactSection("context", ""); got != "56%" {
+		t.Errorf("expected compact context %q, got %q", "56%", got)
+	}
+}
+
+// TestVisibleSections filters sections by their "when" condition
+func TestVisibleSections(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "prism-test-when-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectDir)
+	os.WriteFile(filepath.Join(projectDir, "build.gradle"), nil, 0644)
+
+	sl := &StatusLine{input: Input{Workspace: WorkspaceInfo{ProjectDir: projectDir}}}
+	sl.input.Context.UsedPercentage = 40
+
+	got := sl.visibleSections([]config.Section{
+		{Name: "dir"},
+		{Name: "android_devices", When: "project has build.gradle"},
+		{Name: "usage", When: "context_pct > 50"},
+		{Name: "git", When: "is_git_repo"},
+		{Name: "broken", When: "no_such_field > 1"},
+	})
+
+	expected := []string{"dir", "android_devices"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// TestScenarios renders each preview scenario with its preset plan
+func TestScenarios(t *testing.T) {
+	projectDir := t.TempDir()
+	cfg := config.Config{Sections: []any{"model", "context", "usage"}}
+
+	expected := map[string][]string{
+		"default":          {"Opus 4.5", "42%", "23%"},
+		"low-context":      {"8%"},
+		"near-autocompact": {"93%"},
+		"api-billing":      {"Sonnet 4.5", "$4.87"},
+		"max-plan":         {"64%", "78%", "91%"},
+	}
+
+	scenarios := Scenarios(projectDir)
+	if len(scenarios) != len(expected) {
+		t.Fatalf("expected %d scenarios, got %d", len(expected), len(scenarios))
+	}
+	for _, s := range scenarios {
+		sl := NewPreviewEngine(s.Usage).New(s.Input, cfg)
+		output := colors.Strip(sl.Render())
+		sl.Wait()
+		for _, want := range expected[s.Name] {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: expected %q in %q", s.Name, want, output)
+			}
+		}
+	}
+
+	if _, ok := FindScenario(projectDir, "nope"); ok {
+		t.Error("expected unknown scenario not to be found")
+	}
+}
+
+// TestSetContext keeps the legacy token counts consistent with the percentage
+func TestSetContext(t *testing.T) {
+	in := SampleInput("")
+	in.SetContext(75)
+
+	sl := &StatusLine{input: in, config: config.Config{AutocompactBuffer: new(float64)}}
+	if pct := sl.contextPct(); pct != 75 {
+		t.Errorf("expected 75%%, got %d", pct)
+	}
+	in.Context.UsedPercentage, in.Context.RemainingPercentage = 0, 0
+	sl.input = in
+	if pct := sl.calculateContextPctLegacy(); pct != 75 {
+		t.Errorf("expected legacy 75%%, got %d", pct)
+	}
+}
+
+// TestStats_PluginFailures records why plugin sections are missing or stale
+func TestStats_PluginFailures(t *testing.T) {
+	fake := &fakePlugin{name: "test_stats_slow", output: "fresh"}
+	sl := newFakePluginStatusLine(t, fake)
+	sl.runPlugin(fake.name)
+
+	fake.delay = sectionTimeout + 100*time.Millisecond
+	sl.timeSection(fake.name, func() string { return sl.runPlugin(fake.name) })
+	sl.Wait()
+
+	stats := sl.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("expected one stat, got %+v", stats)
+	}
+	s := stats[0]
+	if s.Kind != "native" || !s.TimedOut || s.Cache != "hit" || s.Refresh < sectionTimeout {
+		t.Errorf("unexpected stat for slow plugin: %+v", s)
+	}
+	if result := s.Result(); !strings.HasPrefix(result, "timeout, stale value shown, finished after") {
+		t.Errorf("unexpected result %q", result)
+	}
+
+	failing := &fakePlugin{name: "test_stats_error", err: errors.New("adb: device offline")}
+	sl = newFakePluginStatusLine(t, failing)
+	sl.timeSection(failing.name, func() string { return sl.runPlugin(failing.name) })
+	if got := sl.Stats()[0].Result(); got != "error, adb: device offline" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if sl.Stats()[0].Cache != "miss" {
+		t.Errorf("expected a cache miss, got %+v", sl.Stats()[0])
+	}
+}
+
+// TestStats_HiddenSections explains sections that render nothing
+func TestStats_HiddenSections(t *testing.T) {
+	sl := newFakePluginStatusLine(t, &fakePlugin{name: "test_stats_unused"})
+	sl.config = config.Config{Sections: []any{
+		"model",
+		"no_such_section",
+		map[string]any{"section": "context", "when": "context_pct > 50"},
+	}}
+	sl.input.Model.DisplayName = "Opus"
+	sl.Render()
+
+	results := make(map[string]string)
+	for _, s := range sl.Stats() {
+		results[s.Name] = s.Result()
+	}
+	expected := map[string]string{
+		"model":           "ok",
+		"no_such_section": "no section or plugin with this name",
+		"context":         `hidden by when "context_pct > 50"`,
+	}
+	for name, want := range expected {
+		if results[name] != want {
+			t.Errorf("%s: expected %q, got %q", name, want, results[name])
+		}
+	}
+}
+
+// TestRender_Budget returns at the render budget instead of waiting for the
+// slowest section, which finishes in the background
+func TestRender_Budget(t *testing.T) {
+	fake := &fakePlugin{name: "test_budget_slow", output: "slow", delay: 300 * time.Millisecond}
+	sl := newFakePluginStatusLine(t, fake)
+	sl.input.Model.DisplayName = "Opus"
+	sl.config = config.Config{Sections: []any{"model", fake.name}, RenderBudget: 50}
+
+	start := time.Now()
+	output := colors.Strip(sl.Render())
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("render took %s, over its 50ms budget", elapsed)
+	}
+	if output != "Opus" {
+		t.Errorf("expected only the fast section, got %q", output)
+	}
+
+	sl.Wait()
+	var stat SectionStat
+	for _, s := range sl.Stats() {
+		if s.Name == fake.name {
+			stat = s
+		}
+	}
+	if !stat.TimedOut || stat.Hidden != "over render budget" || stat.Refresh < fake.delay {
+		t.Errorf("unexpected stat %+v", stat)
+	}
+
+	// The background run left a value for the next render to show, dimmed
+	next := newFakePluginStatusLine(t, fake)
+	next.input = sl.input
+	next.lastGood = sl.lastGood
+	next.config = sl.config
+	if got := next.Render(); !strings.Contains(got, colors.Wrap(colors.Dim, "slow")) {
+		t.Errorf("expected the stale value, got %q", got)
+	}
+	next.Wait()
+}
+
+// TestRender_ShowsOneUsageSection keeps the first usage section with output,
+// on every render, however renders overlap
+func TestRender_ShowsOneUsageSection(t *testing.T) {
+	bars := &fakePlugin{name: "usage_bars", output: "bars"}
+	sl := newFakePluginStatusLine(t, bars)
+	sl.nativePlugins.Register(&fakePlugin{name: "usage", output: "limits"})
+	sl.nativePlugins.Register(&fakePlugin{name: "usage_text", output: ""})
+	sl.config = config.Config{Sections: []any{"usage_text", "usage_bars", "usage"}}
+
+	for i := 0; i < 3; i++ {
+		if got := colors.Strip(sl.Render()); got != "bars" {
+			t.Errorf("render %d: expected only the first usage section, got %q", i, got)
+		}
+	}
+	sl.Wait()
+}
+
+// TestExecutePlugin_Manifest applies a plugin manifest at render time:
+// config defaults reach the plugin, and a plugin missing a required
+// command is hidden with the reason
+func TestExecutePlugin_Manifest(t *testing.T) {
+	dir := t.TempDir()
+	echo := filepath.Join(dir, "prism-plugin-echo.sh")
+	os.WriteFile(echo, []byte("#!/bin/sh\ncat\n"), 0755)
+	ios := filepath.Join(dir, "prism-plugin-ios.sh")
+	os.WriteFile(ios, []byte("#!/bin/sh\necho sim\n"), 0755)
+
+	sl := newFakePluginStatusLine(t, &fakePlugin{name: "test_manifest_unused"})
+	sl.bashPluginsOnce.Do(func() {
+		sl.bashPlugins = []plugin.Plugin{
+			{Name: "echo", Path: echo, Metadata: plugin.Metadata{
+				Name:   "echo",
+				Config: map[string]plugin.ConfigOption{"greeting": {Type: "string", Default: "hello"}},
+			}},
+			{Name: "ios", Path: ios, Metadata: plugin.Metadata{Name: "ios", Requires: []string{"prism-no-such-xcrun"}}},
+		}
+	})
+
+	output, err := sl.executePlugin("echo", sl.buildPluginInput("echo"))
+	if err != nil || !strings.Contains(output, `"greeting":"hello"`) {
+		t.Errorf("plugin input = %s, %v; want the manifest default", output, err)
+	}
+
+	if output, err := sl.executePlugin("ios", sl.buildPluginInput("ios")); output != "" || err != nil {
+		t.Errorf("ios = %q, %v; want hidden", output, err)
+	}
+	for _, s := range sl.Stats() {
+		if s.Name == "ios" && !strings.Contains(s.Hidden, "prism-no-such-xcrun") {
+			t.Errorf("ios hidden reason = %q", s.Hidden)
+		}
+	}
+}